@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// checkCompatibility checks whether the plugin supports the version of the host, if it is set.
+func (r *FsRegistry) checkCompatibility(ctx context.Context, p *plugin.Plugin, msg string) error {
+	if r.hostVersion == nil {
+		return nil
+	}
+
+	if err := p.CheckCompatibility(*r.hostVersion); err != nil {
+		return ctxd.WrapError(ctx, err, msg, "name", p.Name)
+	}
+
+	return nil
+}
+
+// IncompatiblePlugins returns the installed plugins that do not support the version of the host, for example after
+// upgrading the host. It is empty if the version of the host is not set.
+func (r *FsRegistry) IncompatiblePlugins() (plugin.Plugins, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.hostVersion == nil {
+		return plugin.Plugins{}, nil
+	}
+
+	return cfg.Plugins.FilterIncompatible(*r.hostVersion), nil
+}
+
+// WithHostVersion sets the version of the host. Once set, the plugins that do not support it are rejected on install,
+// upgrade and enable.
+func WithHostVersion(v plugin.Version) Option {
+	return func(r *FsRegistry) {
+		r.hostVersion = &v
+	}
+}
@@ -0,0 +1,99 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestRegistry_HostVersion(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/v1", &plugin.Plugin{
+		Name:          "plugin-v1",
+		Version:       "v1.0.0",
+		Enabled:       true,
+		Compatibility: plugin.Compatibility{Host: plugin.MustParseConstraint("^1.2")},
+	})
+	installerMock.RegisterPlugin(source+"/any", &plugin.Plugin{
+		Name:          "plugin-any",
+		Version:       "v1.0.0",
+		Enabled:       true,
+		Compatibility: plugin.Compatibility{Host: plugin.MustParseConstraint("")},
+	})
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(fs), registry.WithHostVersion(plugin.MustParseVersion("1.4.0")))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source+"/v1"))
+	require.NoError(t, r.Install(context.Background(), source+"/any"))
+
+	incompatible, err := r.IncompatiblePlugins()
+	require.NoError(t, err)
+
+	assert.Empty(t, incompatible)
+
+	// Upgrade the host.
+	r, err = registry.NewRegistry("/tmp", registry.WithFs(fs), registry.WithHostVersion(plugin.MustParseVersion("2.0.0")))
+	require.NoError(t, err)
+
+	incompatible, err = r.IncompatiblePlugins()
+	require.NoError(t, err)
+
+	assert.True(t, incompatible.Has("plugin-v1"))
+	assert.Len(t, incompatible, 1)
+
+	require.NoError(t, r.Disable("plugin-v1"))
+
+	err = r.Enable("plugin-v1")
+	require.EqualError(t, err, "could not enable plugin: plugin is not compatible with the host: plugin-v1 v1.0.0 requires host ^1.2, got 2.0.0")
+
+	var incompatibleErr *plugin.IncompatibleError
+
+	require.ErrorAs(t, err, &incompatibleErr)
+	assert.Equal(t, "plugin-v1", incompatibleErr.Name)
+
+	require.NoError(t, r.Uninstall("plugin-v1"))
+
+	err = r.Install(context.Background(), source+"/v1")
+	require.ErrorIs(t, err, plugin.ErrIncompatible)
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.False(t, cfg.Plugins.Has("plugin-v1"))
+}
+
+func TestRegistry_IncompatiblePlugins_NoHostVersion(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source, &plugin.Plugin{
+		Name:          "my-plugin",
+		Version:       "v1.0.0",
+		Enabled:       true,
+		Compatibility: plugin.Compatibility{Host: plugin.MustParseConstraint("^1.2")},
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source))
+
+	incompatible, err := r.IncompatiblePlugins()
+	require.NoError(t, err)
+
+	assert.Empty(t, incompatible)
+}
@@ -2,23 +2,31 @@ package config
 
 import (
 	"context"
+	"io"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/bool64/ctxd"
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 
+	"github.com/nhatthm/plugin-registry/lock"
 	"github.com/nhatthm/plugin-registry/plugin"
 )
 
+// DefaultLockTimeout is the default duration to wait for the lock of the configuration file.
+const DefaultLockTimeout = 10 * time.Second
+
 var _ Configurator = (*FileConfigurator)(nil)
 
 // FileConfigurator is a file configurator.
 type FileConfigurator struct {
 	fs afero.Fs
 
-	configFile string
+	configFile  string
+	lockTimeout time.Duration
+	backup      bool
 
 	mu sync.Mutex
 }
@@ -31,6 +39,43 @@ func (c *FileConfigurator) unlock() {
 	c.mu.Unlock()
 }
 
+// lockFile acquires the lock of the configuration file to prevent other processes from modifying it.
+func (c *FileConfigurator) lockFile() (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.lockTimeout)
+	defer cancel()
+
+	l := lock.New(c.fs, c.configFile+".lock")
+
+	if err := l.Lock(ctx); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = l.Unlock() //nolint: errcheck
+	}, nil
+}
+
+// WithLockTimeout sets the duration to wait for the lock of the configuration file.
+func (c *FileConfigurator) WithLockTimeout(d time.Duration) *FileConfigurator {
+	c.lock()
+	defer c.unlock()
+
+	c.lockTimeout = d
+
+	return c
+}
+
+// WithBackup keeps the previous version of the configuration file as a backup. The backup is used when the
+// configuration file is corrupted.
+func (c *FileConfigurator) WithBackup() *FileConfigurator {
+	c.lock()
+	defer c.unlock()
+
+	c.backup = true
+
+	return c
+}
+
 // WithFs sets file system for the configurator.
 func (c *FileConfigurator) WithFs(fs afero.Fs) *FileConfigurator {
 	c.lock()
@@ -54,6 +99,13 @@ func (c *FileConfigurator) SetPlugin(p plugin.Plugin) error {
 	c.lock()
 	defer c.unlock()
 
+	release, err := c.lockFile()
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
 	cfg, err := c.loadLocked()
 	if err != nil {
 		return err
@@ -73,6 +125,13 @@ func (c *FileConfigurator) RemovePlugin(name string) error {
 	c.lock()
 	defer c.unlock()
 
+	release, err := c.lockFile()
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
 	cfg, err := c.loadLocked()
 	if err != nil {
 		return err
@@ -92,6 +151,13 @@ func (c *FileConfigurator) EnablePlugin(name string) error {
 	c.lock()
 	defer c.unlock()
 
+	release, err := c.lockFile()
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
 	cfg, err := c.loadLocked()
 	if err != nil {
 		return err
@@ -113,6 +179,13 @@ func (c *FileConfigurator) DisablePlugin(name string) error {
 	c.lock()
 	defer c.unlock()
 
+	release, err := c.lockFile()
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
 	cfg, err := c.loadLocked()
 	if err != nil {
 		return err
@@ -129,19 +202,78 @@ func (c *FileConfigurator) DisablePlugin(name string) error {
 	return c.writeLocked(cfg)
 }
 
+// writeLocked writes the configuration to a temporary file then renames it, so the configuration file is never left
+// truncated. The previous version is kept as a backup if enabled.
 func (c *FileConfigurator) writeLocked(cfg Configuration) error {
-	f, err := c.fs.OpenFile(c.configFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)) //nolint: nosnakecase
+	if c.backup {
+		if err := c.backupLocked(); err != nil {
+			return err
+		}
+	}
+
+	return c.writeFileLocked(c.configFile, func(w io.Writer) error {
+		return yaml.NewEncoder(w).Encode(cfg)
+	})
+}
+
+// writeFileLocked writes to a temporary file, syncs it to the disk then renames it to the destination.
+func (c *FileConfigurator) writeFileLocked(path string, write func(w io.Writer) error) error {
+	tmpFile := path + ".tmp"
+
+	f, err := c.fs.OpenFile(tmpFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)) //nolint: nosnakecase
 	if err != nil {
 		return err
 	}
-	defer f.Close() //nolint: errcheck
 
-	enc := yaml.NewEncoder(f)
+	err = write(f)
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = c.fs.Remove(tmpFile) //nolint: errcheck
+
+		return err
+	}
 
-	return enc.Encode(cfg)
+	return c.fs.Rename(tmpFile, path)
 }
 
-// loadLocked loads the current configuration.
+// backupLocked copies the current configuration file to the backup file. A corrupted configuration file is not backed
+// up to keep the last good version.
+func (c *FileConfigurator) backupLocked() error {
+	data, err := afero.ReadFile(c.fs, c.configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	var cfg Configuration
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil //nolint: nilerr
+	}
+
+	return c.writeFileLocked(c.backupFile(), func(w io.Writer) error {
+		_, err := w.Write(data)
+
+		return err
+	})
+}
+
+func (c *FileConfigurator) backupFile() string {
+	return c.configFile + ".bak"
+}
+
+// loadLocked loads the current configuration. If the configuration file is corrupted, the backup is used if enabled.
 func (c *FileConfigurator) loadLocked() (Configuration, error) {
 	if exists, err := afero.Exists(c.fs, c.configFile); !exists {
 		return Configuration{}, err
@@ -153,14 +285,38 @@ func (c *FileConfigurator) loadLocked() (Configuration, error) {
 	}
 	defer f.Close() //nolint: errcheck
 
-	var cfg Configuration
+	cfg, err := decodeConfig(f)
+	if err == nil {
+		return cfg, nil
+	}
 
-	dec := yaml.NewDecoder(f)
+	if c.backup {
+		if cfg, bakErr := c.loadBackupLocked(); bakErr == nil {
+			return cfg, nil
+		}
+	}
+
+	return Configuration{}, ctxd.WrapError(context.Background(), err, "could not load configuration",
+		"path", c.configFile,
+	)
+}
 
-	if err := dec.Decode(&cfg); err != nil {
-		return Configuration{}, ctxd.WrapError(context.Background(), err, "could not load configuration",
-			"path", c.configFile,
-		)
+// loadBackupLocked loads the configuration from the backup file.
+func (c *FileConfigurator) loadBackupLocked() (Configuration, error) {
+	f, err := c.fs.Open(c.backupFile())
+	if err != nil {
+		return Configuration{}, err
+	}
+	defer f.Close() //nolint: errcheck
+
+	return decodeConfig(f)
+}
+
+func decodeConfig(r io.Reader) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil {
+		return Configuration{}, err
 	}
 
 	return cfg, nil
@@ -169,7 +325,8 @@ func (c *FileConfigurator) loadLocked() (Configuration, error) {
 // NewFileConfigurator initiates a new FileConfigurator.
 func NewFileConfigurator(configFile string) *FileConfigurator {
 	return &FileConfigurator{
-		fs:         afero.NewOsFs(),
-		configFile: configFile,
+		fs:          afero.NewOsFs(),
+		configFile:  configFile,
+		lockTimeout: DefaultLockTimeout,
 	}
 }
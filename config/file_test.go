@@ -1,12 +1,16 @@
 package config_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/afero/mem"
@@ -15,6 +19,7 @@ import (
 	"go.nhat.io/aferomock"
 
 	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/lock"
 	"github.com/nhatthm/plugin-registry/plugin"
 )
 
@@ -40,6 +45,15 @@ func assertConfigFile(t *testing.T, fs afero.Fs, name string, expected string) b
 	return assert.Equal(t, expected, string(actual))
 }
 
+func expectLockFile(fs *aferomock.Fs) {
+	//nolint: nosnakecase
+	fs.On("OpenFile", "config.yaml.lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o644)).
+		Return(mem.NewFileHandle(mem.CreateFile("config.yaml.lock")), nil)
+
+	fs.On("Remove", "config.yaml.lock").
+		Return(nil)
+}
+
 func TestFileConfigurator_Config(t *testing.T) {
 	t.Parallel()
 
@@ -129,6 +143,39 @@ plugins:
 				},
 			},
 		},
+		{
+			scenario: "version before semantic versions",
+			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				cfg := `
+plugins:
+    my-plugin:
+        name: my-plugin
+        url: ""
+        version: "1.0"
+        enabled: true
+`
+
+				fs.On("Stat", "config.yaml").
+					Return(aferomock.NopFileInfo(t), nil)
+
+				fs.On("Open", "config.yaml").
+					Return(makeConfigFile(cfg), nil)
+			}),
+			expectedConfig: config.Configuration{
+				Plugins: map[string]plugin.Plugin{
+					"my-plugin": {
+						Name:    "my-plugin",
+						Version: "1.0",
+						Enabled: true,
+						Artifacts: plugin.Artifacts{
+							plugin.RuntimeArtifactIdentifier(): {
+								File: "${name}-${version}-${os}-${arch}.tar.gz",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -158,9 +205,20 @@ func TestFileConfigurator_SetPlugin_Error(t *testing.T) {
 		mockFs        aferomock.FsMocker
 		expectedError string
 	}{
+		{
+			scenario: "could not acquire lock",
+			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				//nolint: nosnakecase
+				fs.On("OpenFile", "config.yaml.lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o644)).
+					Return(nil, errors.New("lock error"))
+			}),
+			expectedError: "lock error",
+		},
 		{
 			scenario: "could not load config",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, errors.New("load error"))
 			}),
@@ -169,15 +227,34 @@ func TestFileConfigurator_SetPlugin_Error(t *testing.T) {
 		{
 			scenario: "could not open file for write",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, os.ErrNotExist)
 
 				//nolint: nosnakecase
-				fs.On("OpenFile", "config.yaml", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
+				fs.On("OpenFile", "config.yaml.tmp", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
 					Return(nil, errors.New("open error"))
 			}),
 			expectedError: "open error",
 		},
+		{
+			scenario: "could not rename file",
+			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
+				fs.On("Stat", "config.yaml").
+					Return(nil, os.ErrNotExist)
+
+				//nolint: nosnakecase
+				fs.On("OpenFile", "config.yaml.tmp", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
+					Return(mem.NewFileHandle(mem.CreateFile("config.yaml.tmp")), nil)
+
+				fs.On("Rename", "config.yaml.tmp", "config.yaml").
+					Return(errors.New("rename error"))
+			}),
+			expectedError: "rename error",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -238,6 +315,120 @@ func TestFileConfigurator_SetPlugin_Success(t *testing.T) {
 	assertConfigFile(t, fs, "config.yaml", expected)
 }
 
+func TestFileConfigurator_SetPlugin_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const numPlugins = 10
+
+	fs := afero.NewOsFs()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		i := i
+		c := config.NewFileConfigurator(configFile).WithFs(fs)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < numPlugins; j++ {
+				err := c.SetPlugin(plugin.Plugin{Name: fmt.Sprintf("plugin-%d-%d", i, j)})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	cfg, err := config.NewFileConfigurator(configFile).WithFs(fs).Config()
+	require.NoError(t, err)
+
+	assert.Len(t, cfg.Plugins, 2*numPlugins)
+}
+
+func TestFileConfigurator_SetPlugin_Locked(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	err := lock.New(fs, "config.yaml.lock").Lock(context.Background())
+	require.NoError(t, err)
+
+	c := config.NewFileConfigurator("config.yaml").
+		WithFs(fs).
+		WithLockTimeout(10 * time.Millisecond)
+
+	err = c.SetPlugin(plugin.Plugin{Name: "my-plugin"})
+
+	require.ErrorIs(t, err, lock.ErrLocked)
+}
+
+func TestFileConfigurator_Backup(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	err := writeConfigFile(fs, "plugins: {}\n")
+	require.NoError(t, err)
+
+	c := config.NewFileConfigurator("config.yaml").WithFs(fs).WithBackup()
+
+	err = c.SetPlugin(plugin.Plugin{Name: "my-plugin", Tags: plugin.Tags{}})
+	require.NoError(t, err)
+
+	assertConfigFile(t, fs, "config.yaml.bak", "plugins: {}\n")
+
+	// The configuration file is corrupted, the backup is used instead.
+	err = writeConfigFile(fs, "plugins: [")
+	require.NoError(t, err)
+
+	cfg, err := c.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Configuration{Plugins: plugin.Plugins{}}, cfg)
+
+	// The corrupted file does not overwrite the backup.
+	err = c.SetPlugin(plugin.Plugin{Name: "another-plugin", Tags: plugin.Tags{}})
+	require.NoError(t, err)
+
+	assertConfigFile(t, fs, "config.yaml.bak", "plugins: {}\n")
+
+	cfg, err = c.Config()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Plugins.Has("another-plugin"))
+	assert.False(t, cfg.Plugins.Has("my-plugin"))
+
+	exists, err := afero.Exists(fs, "config.yaml.tmp")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestFileConfigurator_NoBackup(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	err := writeConfigFile(fs, "plugins: {}\n")
+	require.NoError(t, err)
+
+	c := config.NewFileConfigurator("config.yaml").WithFs(fs)
+
+	err = c.SetPlugin(plugin.Plugin{Name: "my-plugin"})
+	require.NoError(t, err)
+
+	exists, err := afero.Exists(fs, "config.yaml.bak")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	err = writeConfigFile(fs, "plugins: [")
+	require.NoError(t, err)
+
+	_, err = c.Config()
+	require.ErrorContains(t, err, "could not load configuration")
+}
+
 func TestFileConfigurator_RemovePlugin_Error(t *testing.T) {
 	t.Parallel()
 
@@ -262,6 +453,8 @@ func TestFileConfigurator_RemovePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not load config",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, errors.New("load error"))
 			}),
@@ -270,6 +463,8 @@ func TestFileConfigurator_RemovePlugin_Error(t *testing.T) {
 		{
 			scenario: "plugin not found",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, os.ErrNotExist)
 			}),
@@ -278,6 +473,8 @@ func TestFileConfigurator_RemovePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not open file for write",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(aferomock.NopFileInfo(t), nil)
 
@@ -285,7 +482,7 @@ func TestFileConfigurator_RemovePlugin_Error(t *testing.T) {
 					Return(makeConfigFile(cfg), nil)
 
 				//nolint: nosnakecase
-				fs.On("OpenFile", "config.yaml", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
+				fs.On("OpenFile", "config.yaml.tmp", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
 					Return(nil, errors.New("open error"))
 			}),
 			expectedError: "open error",
@@ -363,6 +560,8 @@ func TestFileConfigurator_EnablePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not load config",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, errors.New("load error"))
 			}),
@@ -371,6 +570,8 @@ func TestFileConfigurator_EnablePlugin_Error(t *testing.T) {
 		{
 			scenario: "plugin not found",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(aferomock.NopFileInfo(t), nil)
 
@@ -383,6 +584,8 @@ func TestFileConfigurator_EnablePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not open file for write",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(aferomock.NopFileInfo(t), nil)
 
@@ -390,7 +593,7 @@ func TestFileConfigurator_EnablePlugin_Error(t *testing.T) {
 					Return(makeConfigFile(cfg), nil)
 
 				//nolint: nosnakecase
-				fs.On("OpenFile", "config.yaml", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
+				fs.On("OpenFile", "config.yaml.tmp", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
 					Return(nil, errors.New("open error"))
 			}),
 			expectedError: "open error",
@@ -484,6 +687,8 @@ func TestFileConfigurator_DisablePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not load config",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(nil, errors.New("load error"))
 			}),
@@ -492,6 +697,8 @@ func TestFileConfigurator_DisablePlugin_Error(t *testing.T) {
 		{
 			scenario: "plugin not found",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(aferomock.NopFileInfo(t), nil)
 
@@ -504,6 +711,8 @@ func TestFileConfigurator_DisablePlugin_Error(t *testing.T) {
 		{
 			scenario: "could not open file for write",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectLockFile(fs)
+
 				fs.On("Stat", "config.yaml").
 					Return(aferomock.NopFileInfo(t), nil)
 
@@ -511,7 +720,7 @@ func TestFileConfigurator_DisablePlugin_Error(t *testing.T) {
 					Return(makeConfigFile(cfg), nil)
 
 				//nolint: nosnakecase
-				fs.On("OpenFile", "config.yaml", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
+				fs.On("OpenFile", "config.yaml.tmp", os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)).
 					Return(nil, errors.New("open error"))
 			}),
 			expectedError: "open error",
@@ -28,6 +28,14 @@ func (c *MemConfigurator) init() error {
 	return nil
 }
 
+// Reload reloads the configuration from the upstream, for example after another process has changed it.
+func (c *MemConfigurator) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.init()
+}
+
 // Config returns the current configuration.
 func (c *MemConfigurator) Config() (Configuration, error) {
 	c.mu.Lock()
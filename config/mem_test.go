@@ -103,6 +103,33 @@ func TestMemConfigurator_Config(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestMemConfigurator_Reload(t *testing.T) {
+	t.Parallel()
+
+	upstream := configurator.Mock(func(c *configurator.Configurator) {
+		c.On("Config").Once().
+			Return(config.Configuration{}, nil)
+
+		c.On("Config").Once().
+			Return(config.Configuration{Plugins: plugin.Plugins{"my-plugin": {Name: "my-plugin"}}}, nil)
+
+		c.On("Config").Once().
+			Return(config.Configuration{}, errors.New("upstream error"))
+	})(t)
+
+	c, err := config.NewMemConfigurator(upstream)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Reload())
+
+	actual, err := c.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Configuration{Plugins: plugin.Plugins{"my-plugin": {Name: "my-plugin"}}}, actual)
+
+	require.EqualError(t, c.Reload(), "upstream error")
+}
+
 func TestMemConfigurator_SetPlugin(t *testing.T) {
 	t.Parallel()
 
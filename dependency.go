@@ -0,0 +1,251 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+var (
+	// ErrDependencyCycle indicates that the plugins require each other.
+	ErrDependencyCycle = errors.New("plugin dependency cycle")
+	// ErrDependencyConflict indicates that the version of a required plugin does not satisfy the constraint.
+	ErrDependencyConflict = errors.New("plugin dependency conflict")
+	// ErrDependencyNoSource indicates that a required plugin is not installed and there is no source to install it from.
+	ErrDependencyNoSource = errors.New("required plugin is not installed and has no source")
+	// ErrDependencyNotInstalled indicates that a required plugin is not installed.
+	ErrDependencyNotInstalled = errors.New("required plugin is not installed")
+	// ErrDependencyDisabled indicates that a required plugin is disabled.
+	ErrDependencyDisabled = errors.New("required plugin is disabled")
+	// ErrPluginRequired indicates that the plugin is required by other enabled plugins.
+	ErrPluginRequired = errors.New("plugin is required by other plugins")
+)
+
+// resolveDependencies installs the plugins that are required by the plugin and are missing, recursively. The resolving
+// list contains the plugins that are being installed, it is used to detect cycles. The installed plugins are added to
+// the events.
+func (r *FsRegistry) resolveDependencies(ctx context.Context, p *plugin.Plugin, resolving []string, events *[]Event) error {
+	if err := r.checkDependents(ctx, p); err != nil {
+		return err
+	}
+
+	resolving = append(resolving[:len(resolving):len(resolving)], p.Name)
+
+	for _, req := range p.Requires {
+		if containsString(resolving, req.Name) {
+			return ctxd.WrapError(ctx, ErrDependencyCycle, "could not resolve dependencies",
+				"name", p.Name,
+				"cycle", strings.Join(append(resolving, req.Name), " -> "),
+			)
+		}
+
+		installed, err := r.GetPlugin(req.Name)
+		if err != nil {
+			return err
+		}
+
+		if installed != nil {
+			if !satisfies(req.Version, installed.Version) {
+				return ctxd.WrapError(ctx, ErrDependencyConflict, "could not resolve dependencies",
+					"name", req.Name,
+					"version", installed.Version,
+					"constraint", req.Version.String(),
+					"required_by", p.Name,
+				)
+			}
+
+			continue
+		}
+
+		if req.Source == "" {
+			return ctxd.WrapError(ctx, ErrDependencyNoSource, "could not resolve dependencies",
+				"name", req.Name,
+				"required_by", p.Name,
+			)
+		}
+
+		dep := installer.Request{Source: req.Source, Name: req.Name, Version: req.Version}
+
+		if err := r.install(ctx, dep, resolving, events); err != nil {
+			return ctxd.WrapError(ctx, err, "could not install required plugin",
+				"name", req.Name,
+				"required_by", p.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkDependents checks whether the new version of the plugin still satisfies the constraints of the enabled plugins
+// that require it.
+func (r *FsRegistry) checkDependents(ctx context.Context, p *plugin.Plugin) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedNames(cfg.Plugins) {
+		d := cfg.Plugins[name]
+
+		if !d.Enabled || name == p.Name {
+			continue
+		}
+
+		for _, req := range d.Requires {
+			if req.Name == p.Name && !satisfies(req.Version, p.Version) {
+				return ctxd.WrapError(ctx, ErrDependencyConflict, "could not resolve dependencies",
+					"name", p.Name,
+					"version", p.Version,
+					"constraint", req.Version.String(),
+					"required_by", name,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRequirements checks whether the plugins that are required by the plugin are installed, enabled, and satisfy the
+// constraints.
+func (r *FsRegistry) checkRequirements(ctx context.Context, p *plugin.Plugin) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	for _, req := range p.Requires {
+		d, ok := cfg.Plugins[req.Name]
+
+		switch {
+		case !ok:
+			return ctxd.WrapError(ctx, ErrDependencyNotInstalled, "could not enable plugin",
+				"name", req.Name,
+				"required_by", p.Name,
+			)
+
+		case !d.Enabled:
+			return ctxd.WrapError(ctx, ErrDependencyDisabled, "could not enable plugin",
+				"name", req.Name,
+				"required_by", p.Name,
+			)
+
+		case !satisfies(req.Version, d.Version):
+			return ctxd.WrapError(ctx, ErrDependencyConflict, "could not enable plugin",
+				"name", req.Name,
+				"version", d.Version,
+				"constraint", req.Version.String(),
+				"required_by", p.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+// checkRequired returns an error if the plugin is required by other enabled plugins, wrapped with the message of the
+// operation.
+func (r *FsRegistry) checkRequired(name, msg string) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	if dependents := requiredBy(cfg.Plugins, name); len(dependents) > 0 {
+		return ctxd.WrapError(context.Background(), ErrPluginRequired, msg,
+			"name", name,
+			"required_by", dependents,
+		)
+	}
+
+	return nil
+}
+
+// requiredBy returns the names of the enabled plugins that require the plugin.
+func requiredBy(plugins plugin.Plugins, name string) []string {
+	var result []string
+
+	for n, p := range plugins {
+		if n != name && p.Enabled && p.Requires.Has(name) {
+			result = append(result, n)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// removalOrder sorts the plugins to remove, or to disable, so that a plugin comes before the plugins it requires.
+func removalOrder(plugins plugin.Plugins, names []string) []string {
+	return dependencyOrder(names, func(name, other string) bool {
+		return plugins[other].Requires.Has(name)
+	})
+}
+
+// enableOrder sorts the plugins to enable so that a plugin comes after the plugins it requires.
+func enableOrder(plugins plugin.Plugins, names []string) []string {
+	return dependencyOrder(names, func(name, other string) bool {
+		return plugins[name].Requires.Has(other)
+	})
+}
+
+// dependencyOrder sorts the names so that a name comes after the names it waits for, the order of the names is kept
+// otherwise.
+func dependencyOrder(names []string, waitsFor func(name, other string) bool) []string {
+	result := make([]string, 0, len(names))
+	done := make(map[string]bool, len(names))
+
+	isWaiting := func(name string) bool {
+		for _, n := range names {
+			if n != name && !done[n] && waitsFor(name, n) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for len(result) < len(names) {
+		progress := false
+
+		for _, name := range names {
+			if done[name] || isWaiting(name) {
+				continue
+			}
+
+			result = append(result, name)
+			done[name] = true
+			progress = true
+		}
+
+		// The remaining names wait for each other.
+		if !progress {
+			for _, name := range names {
+				if !done[name] {
+					result = append(result, name)
+					done[name] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
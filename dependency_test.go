@@ -0,0 +1,261 @@
+package registry_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestRegistry_InstallRequest_Dependencies(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/app", &plugin.Plugin{
+		Name:     "app",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "lib", Version: plugin.MustParseConstraint("^1"), Source: source + "/lib"}},
+	})
+	installerMock.RegisterPlugin(source+"/lib", &plugin.Plugin{
+		Name:     "lib",
+		Version:  "v1.2.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "core", Source: source + "/core"}},
+	})
+	installerMock.RegisterPlugin(source+"/core", &plugin.Plugin{
+		Name:    "core",
+		Version: "v0.1.0",
+		Enabled: true,
+	})
+	installerMock.RegisterPlugin(source+"/lib2", &plugin.Plugin{
+		Name:    "lib",
+		Version: "v2.0.0",
+		Enabled: true,
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), source+"/app")
+	require.NoError(t, err)
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", cfg.Plugins["app"].Version)
+	assert.Equal(t, "v1.2.0", cfg.Plugins["lib"].Version)
+	assert.Equal(t, "v0.1.0", cfg.Plugins["core"].Version)
+
+	// The new version of lib does not satisfy the constraint of app.
+	err = r.Install(context.Background(), source+"/lib2")
+	require.ErrorIs(t, err, registry.ErrDependencyConflict)
+
+	// The required plugins can not be removed.
+	err = r.Uninstall("core")
+	require.ErrorIs(t, err, registry.ErrPluginRequired)
+
+	err = r.Disable("lib")
+	require.ErrorIs(t, err, registry.ErrPluginRequired)
+
+	err = r.ForceDisable("lib")
+	require.NoError(t, err)
+
+	// lib is disabled, so core is not required anymore.
+	err = r.Uninstall("core")
+	require.NoError(t, err)
+
+	err = r.ForceUninstall("lib")
+	require.NoError(t, err)
+
+	cfg, err = r.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"app"}, pluginNames(cfg.Plugins))
+}
+
+func TestRegistry_InstallRequest_DependencyError(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/cycle-a", &plugin.Plugin{
+		Name:     "cycle-a",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "cycle-b", Source: source + "/cycle-b"}},
+	})
+	installerMock.RegisterPlugin(source+"/cycle-b", &plugin.Plugin{
+		Name:     "cycle-b",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "cycle-a", Source: source + "/cycle-a"}},
+	})
+	installerMock.RegisterPlugin(source+"/lib", &plugin.Plugin{
+		Name:    "lib",
+		Version: "v1.0.0",
+		Enabled: true,
+	})
+	installerMock.RegisterPlugin(source+"/conflict", &plugin.Plugin{
+		Name:     "conflict",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "lib", Version: plugin.MustParseConstraint("^2"), Source: source + "/lib"}},
+	})
+	installerMock.RegisterPlugin(source+"/no-source", &plugin.Plugin{
+		Name:     "no-source",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "missing"}},
+	})
+
+	testCases := []struct {
+		scenario      string
+		source        string
+		expectedError error
+	}{
+		{
+			scenario:      "cycle",
+			source:        source + "/cycle-a",
+			expectedError: registry.ErrDependencyCycle,
+		},
+		{
+			scenario:      "conflict",
+			source:        source + "/conflict",
+			expectedError: registry.ErrDependencyConflict,
+		},
+		{
+			scenario:      "no source",
+			source:        source + "/no-source",
+			expectedError: registry.ErrDependencyNoSource,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+			require.NoError(t, err)
+
+			err = r.Install(context.Background(), source+"/lib")
+			require.NoError(t, err)
+
+			err = r.Install(context.Background(), tc.source)
+			require.ErrorIs(t, err, tc.expectedError)
+
+			cfg, err := r.Config()
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"lib"}, pluginNames(cfg.Plugins))
+		})
+	}
+}
+
+func TestRegistry_Plan_RemoveDependents(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/viewer", &plugin.Plugin{
+		Name:     "viewer",
+		Version:  "v1.0.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "lib", Source: source + "/lib"}},
+	})
+	installerMock.RegisterPlugin(source+"/lib", &plugin.Plugin{
+		Name:    "lib",
+		Version: "v1.0.0",
+		Enabled: true,
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), source+"/viewer")
+	require.NoError(t, err)
+
+	plan, err := r.Reconcile(context.Background(), registry.DesiredState{}, false)
+	require.NoError(t, err)
+
+	expected := registry.Plan{Actions: []registry.Action{
+		{Type: registry.ActionRemove, Name: "viewer"},
+		{Type: registry.ActionRemove, Name: "lib"},
+	}}
+
+	assert.Equal(t, expected, plan)
+}
+
+func TestRegistry_Plan_EnableDependencies(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/app", &plugin.Plugin{
+		Name:     "app",
+		Version:  "v1.0.0",
+		Requires: plugin.Requirements{{Name: "lib", Source: source + "/lib"}},
+	})
+	installerMock.RegisterPlugin(source+"/lib", &plugin.Plugin{
+		Name:    "lib",
+		Version: "v1.0.0",
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source+"/app"))
+
+	// The plugin can not be enabled before the plugin it requires.
+	require.ErrorIs(t, r.Enable("app"), registry.ErrDependencyDisabled)
+
+	desired := registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "app", Source: source + "/app", Enabled: true},
+		{Name: "lib", Source: source + "/lib", Enabled: true},
+	}}
+
+	plan, err := r.Reconcile(context.Background(), desired, false)
+	require.NoError(t, err)
+
+	expected := registry.Plan{Actions: []registry.Action{
+		{Type: registry.ActionEnable, Name: "lib"},
+		{Type: registry.ActionEnable, Name: "app"},
+	}}
+
+	assert.Equal(t, expected, plan)
+
+	// The plugin can not be disabled before the plugins that require it.
+	plan, err = r.Reconcile(context.Background(), registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "app", Source: source + "/app"},
+		{Name: "lib", Source: source + "/lib"},
+	}}, false)
+	require.NoError(t, err)
+
+	expected = registry.Plan{Actions: []registry.Action{
+		{Type: registry.ActionDisable, Name: "app"},
+		{Type: registry.ActionDisable, Name: "lib"},
+	}}
+
+	assert.Equal(t, expected, plan)
+}
+
+func pluginNames(plugins plugin.Plugins) []string {
+	names := make([]string, 0, len(plugins))
+
+	for name := range plugins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
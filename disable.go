@@ -1,6 +1,40 @@
 package registry
 
-// Disable disables a plugin by name.
+import "context"
+
+// Disable disables a plugin by name. It is refused if the plugin is required by other enabled plugins.
 func (r *FsRegistry) Disable(name string) error {
+	return r.disable(name, false)
+}
+
+// ForceDisable disables a plugin by name even if it is required by other enabled plugins.
+func (r *FsRegistry) ForceDisable(name string) error {
+	return r.disable(name, true)
+}
+
+func (r *FsRegistry) disable(name string, force bool) error {
+	if err := r.disablePlugin(name, force); err != nil {
+		return err
+	}
+
+	r.notify(Event{Type: EventDisabled, Name: name})
+
+	return nil
+}
+
+func (r *FsRegistry) disablePlugin(name string, force bool) error {
+	release, err := r.lock(context.Background())
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if !force {
+		if err := r.checkRequired(name, "could not disable plugin"); err != nil {
+			return err
+		}
+	}
+
 	return r.config.DisablePlugin(name)
 }
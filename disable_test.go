@@ -4,9 +4,12 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nhatthm/plugin-registry/config"
 	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	"github.com/nhatthm/plugin-registry/plugin"
 )
 
 func TestRegistry_Disable(t *testing.T) {
@@ -17,9 +20,49 @@ func TestRegistry_Disable(t *testing.T) {
 		mockConfig    configuratorMock.Mocker
 		expectedError string
 	}{
+		{
+			scenario: "could not read config",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, errors.New("read error"))
+			}),
+			expectedError: "read error",
+		},
+		{
+			scenario: "required by enabled plugin",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{Plugins: plugin.Plugins{
+						"other-plugin": {
+							Name:     "other-plugin",
+							Enabled:  true,
+							Requires: plugin.Requirements{{Name: "my-plugin"}},
+						},
+					}}, nil)
+			}),
+			expectedError: "could not disable plugin: plugin is required by other plugins",
+		},
+		{
+			scenario: "required by disabled plugin",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{Plugins: plugin.Plugins{
+						"other-plugin": {
+							Name:     "other-plugin",
+							Requires: plugin.Requirements{{Name: "my-plugin"}},
+						},
+					}}, nil)
+
+				c.On("DisablePlugin", "my-plugin").
+					Return(nil)
+			}),
+		},
 		{
 			scenario: "failure",
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("DisablePlugin", "my-plugin").
 					Return(errors.New("disable error"))
 			}),
@@ -28,6 +71,9 @@ func TestRegistry_Disable(t *testing.T) {
 		{
 			scenario: "success",
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("DisablePlugin", "my-plugin").
 					Return(nil)
 			}),
@@ -39,7 +85,7 @@ func TestRegistry_Disable(t *testing.T) {
 		t.Run(tc.scenario, func(t *testing.T) {
 			t.Parallel()
 
-			r, err := NewRegistry("/tmp", WithConfigurator(tc.mockConfig(t)))
+			r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()), WithConfigurator(tc.mockConfig(t)))
 			require.NoError(t, err)
 
 			err = r.Disable("my-plugin")
@@ -52,3 +98,17 @@ func TestRegistry_Disable(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry_ForceDisable(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()), WithConfigurator(configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+		c.On("DisablePlugin", "my-plugin").
+			Return(nil)
+	})(t)))
+	require.NoError(t, err)
+
+	err = r.ForceDisable("my-plugin")
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/runner"
+)
+
+var (
+	// ErrNoCommand indicates that there is no subcommand in the arguments.
+	ErrNoCommand = errors.New("no command")
+	// ErrUnknownCommand indicates that there is no plugin for the subcommand.
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrCommandDisabled indicates that the plugin of the subcommand is disabled.
+	ErrCommandDisabled = errors.New("command is disabled")
+)
+
+// Option configures Dispatcher.
+type Option func(d *Dispatcher)
+
+// Registry provides the installed plugins, it is satisfied by registry.FsRegistry.
+type Registry interface {
+	Config() (config.Configuration, error)
+	GetPlugin(name string) (*plugin.Plugin, error)
+}
+
+// Command is a subcommand provided by a plugin.
+type Command struct {
+	Name        string
+	Description string
+}
+
+// Dispatcher maps the arguments to a plugin and runs it, "mytool foo bar" runs the plugin "foo" with the argument
+// "bar".
+type Dispatcher struct {
+	registry Registry
+	runner   *runner.Runner
+	prefix   string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Dispatch runs the plugin of the subcommand in args[0] with the rest of the arguments, and returns its exit code. The
+// standard input and outputs are forwarded to the plugin, and it runs in the current working directory. Hidden plugins
+// can be run but disabled plugins can not.
+func (d *Dispatcher) Dispatch(ctx context.Context, args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, ErrNoCommand
+	}
+
+	p, err := d.find(ctx, args[0])
+	if err != nil {
+		return 0, err
+	}
+
+	cmd, err := d.runner.Command(ctx, *p, args[1:]...)
+	if err != nil {
+		return 0, err
+	}
+
+	// The subcommands run in the current working directory, like the main command, so that relative paths work.
+	cmd.Dir = ""
+	cmd.Stdin = d.stdin
+	cmd.Stdout = d.stdout
+	cmd.Stderr = d.stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+
+		if ctx.Err() == nil && errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+
+		return cmd.ProcessState.ExitCode(), ctxd.WrapError(ctx, err, "could not run command", "command", args[0])
+	}
+
+	return 0, nil
+}
+
+// Commands returns the subcommands of the enabled plugins that are not hidden, sorted by name.
+func (d *Dispatcher) Commands() ([]Command, error) {
+	cfg, err := d.registry.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Command, 0, len(cfg.Plugins))
+
+	for name, p := range cfg.Plugins {
+		if !p.Enabled || p.Hidden || !strings.HasPrefix(name, d.prefix) || name == d.prefix {
+			continue
+		}
+
+		result = append(result, Command{
+			Name:        strings.TrimPrefix(name, d.prefix),
+			Description: p.Description,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// PrintCommands writes the subcommands and their description for the help output.
+func (d *Dispatcher) PrintCommands(w io.Writer) error {
+	commands, err := d.Commands()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, c := range commands {
+		if _, err := fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Description); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (d *Dispatcher) find(ctx context.Context, command string) (*plugin.Plugin, error) {
+	if command == "" || strings.HasPrefix(command, "-") {
+		return nil, ctxd.WrapError(ctx, ErrUnknownCommand, "could not run command", "command", command)
+	}
+
+	p, err := d.registry.GetPlugin(d.prefix + command)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ctxd.WrapError(ctx, ErrUnknownCommand, "could not run command", "command", command)
+	}
+
+	if !p.Enabled {
+		return nil, ctxd.WrapError(ctx, ErrCommandDisabled, "could not run command", "command", command)
+	}
+
+	return p, nil
+}
+
+// New creates a new dispatcher that runs the plugins of the registry with the runner.
+func New(registry Registry, runner *runner.Runner, options ...Option) *Dispatcher {
+	d := &Dispatcher{
+		registry: registry,
+		runner:   runner,
+		stdin:    os.Stdin,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+	}
+
+	for _, o := range options {
+		o(d)
+	}
+
+	return d
+}
+
+// WithPrefix sets the prefix of the plugin names, for example with the prefix "mytool-", the subcommand "foo" runs the
+// plugin "mytool-foo".
+func WithPrefix(prefix string) Option {
+	return func(d *Dispatcher) {
+		d.prefix = prefix
+	}
+}
+
+// WithStdio sets the standard input and outputs that are forwarded to the plugins. The default is the standard input
+// and outputs of the current process.
+func WithStdio(stdin io.Reader, stdout, stderr io.Writer) Option {
+	return func(d *Dispatcher) {
+		d.stdin = stdin
+		d.stdout = stdout
+		d.stderr = stderr
+	}
+}
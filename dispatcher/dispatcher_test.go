@@ -0,0 +1,233 @@
+package dispatcher_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/dispatcher"
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/runner"
+)
+
+const fixturesDir = "../resources/fixtures"
+
+var _ dispatcher.Registry = (*registry.FsRegistry)(nil)
+
+type staticRegistry struct {
+	plugins plugin.Plugins
+	err     error
+}
+
+func (r staticRegistry) Config() (config.Configuration, error) {
+	return config.Configuration{Plugins: r.plugins}, r.err
+}
+
+func (r staticRegistry) GetPlugin(name string) (*plugin.Plugin, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	p, ok := r.plugins[name]
+	if !ok {
+		return nil, nil //nolint: nilnil
+	}
+
+	return &p, nil
+}
+
+func fixturePlugin(enabled, hidden bool) plugin.Plugin {
+	return plugin.Plugin{
+		Name:        "my-plugin",
+		Version:     "v1.0.0",
+		Description: "My plugin",
+		Enabled:     enabled,
+		Hidden:      hidden,
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}"},
+		},
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario         string
+		registry         staticRegistry
+		options          []runner.Option
+		args             []string
+		expectedExitCode int
+		expectedStdout   string
+		expectedError    string
+	}{
+		{
+			scenario:      "no command",
+			expectedError: "no command",
+		},
+		{
+			scenario:      "flag",
+			args:          []string{"--help"},
+			expectedError: "could not run command: unknown command",
+		},
+		{
+			scenario:      "registry error",
+			registry:      staticRegistry{err: errors.New("read error")},
+			args:          []string{"my-plugin"},
+			expectedError: "read error",
+		},
+		{
+			scenario:      "unknown command",
+			registry:      staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, false)}},
+			args:          []string{"unknown"},
+			expectedError: "could not run command: unknown command",
+		},
+		{
+			scenario:      "disabled",
+			registry:      staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(false, false)}},
+			args:          []string{"my-plugin"},
+			expectedError: "could not run command: command is disabled",
+		},
+		{
+			scenario:       "success",
+			registry:       staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, false)}},
+			args:           []string{"my-plugin", "world", "--flag"},
+			expectedStdout: "hello world --flag\n",
+		},
+		{
+			scenario:       "hidden",
+			registry:       staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, true)}},
+			args:           []string{"my-plugin"},
+			expectedStdout: "hello\n",
+		},
+		{
+			scenario:         "exit code",
+			registry:         staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, false)}},
+			options:          []runner.Option{runner.WithEnv("MY_PLUGIN_EXIT_CODE=5")},
+			args:             []string{"my-plugin"},
+			expectedExitCode: 5,
+			expectedStdout:   "hello\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+
+			d := dispatcher.New(tc.registry, runner.New(fixturesDir, tc.options...),
+				dispatcher.WithStdio(strings.NewReader(""), &stdout, &stderr),
+			)
+
+			exitCode, err := d.Dispatch(context.Background(), tc.args)
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+			assert.Equal(t, tc.expectedStdout, stdout.String())
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "my-plugin v1.0.0\n", stderr.String())
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Dispatch_WithPrefix(t *testing.T) {
+	t.Parallel()
+
+	var stdout bytes.Buffer
+
+	r := staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, false)}}
+	d := dispatcher.New(r, runner.New(fixturesDir),
+		dispatcher.WithPrefix("my-"),
+		dispatcher.WithStdio(strings.NewReader(""), &stdout, &bytes.Buffer{}),
+	)
+
+	exitCode, err := d.Dispatch(context.Background(), []string{"plugin", "world"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "hello world\n", stdout.String())
+}
+
+func TestDispatcher_Dispatch_WorkingDir(t *testing.T) {
+	t.Parallel()
+
+	registryDir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(registryDir, "my-plugin"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(registryDir, "my-plugin", "my-plugin"), []byte("#!/bin/sh\npwd -P\n"), 0o755)) //nolint: gosec
+
+	var stdout bytes.Buffer
+
+	r := staticRegistry{plugins: plugin.Plugins{"my-plugin": fixturePlugin(true, false)}}
+	d := dispatcher.New(r, runner.New(registryDir), dispatcher.WithStdio(strings.NewReader(""), &stdout, &bytes.Buffer{}))
+
+	exitCode, err := d.Dispatch(context.Background(), []string{"my-plugin"})
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	wd, err = filepath.EvalSymlinks(wd)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, wd+"\n", stdout.String())
+}
+
+func TestDispatcher_Commands(t *testing.T) {
+	t.Parallel()
+
+	r := staticRegistry{plugins: plugin.Plugins{
+		"mytool-foo":      {Name: "mytool-foo", Description: "Do foo", Enabled: true},
+		"mytool-bar-baz":  {Name: "mytool-bar-baz", Description: "Do bar and baz", Enabled: true},
+		"mytool-disabled": {Name: "mytool-disabled", Description: "Disabled"},
+		"mytool-hidden":   {Name: "mytool-hidden", Description: "Hidden", Enabled: true, Hidden: true},
+		"other":           {Name: "other", Description: "Other", Enabled: true},
+	}}
+
+	d := dispatcher.New(r, runner.New(fixturesDir), dispatcher.WithPrefix("mytool-"))
+
+	commands, err := d.Commands()
+	require.NoError(t, err)
+
+	expected := []dispatcher.Command{
+		{Name: "bar-baz", Description: "Do bar and baz"},
+		{Name: "foo", Description: "Do foo"},
+	}
+
+	assert.Equal(t, expected, commands)
+
+	var out bytes.Buffer
+
+	err = d.PrintCommands(&out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "  bar-baz  Do bar and baz\n  foo      Do foo\n", out.String())
+}
+
+func TestDispatcher_Commands_Error(t *testing.T) {
+	t.Parallel()
+
+	d := dispatcher.New(staticRegistry{err: errors.New("read error")}, runner.New(fixturesDir))
+
+	_, err := d.Commands()
+	require.EqualError(t, err, "read error")
+
+	err = d.PrintCommands(&bytes.Buffer{})
+	require.EqualError(t, err, "read error")
+}
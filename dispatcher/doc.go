@@ -0,0 +1,2 @@
+// Package dispatcher provides functionalities for running the installed plugins as subcommands, like git plugins.
+package dispatcher
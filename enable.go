@@ -1,6 +1,41 @@
 package registry
 
-// Enable enabled a plugin by name.
+import "context"
+
+// Enable enabled a plugin by name. It is refused if the plugin does not support the version of the host, or if the
+// plugins that it requires are not installed and enabled in a satisfying version.
 func (r *FsRegistry) Enable(name string) error {
+	if err := r.enablePlugin(name); err != nil {
+		return err
+	}
+
+	r.notify(Event{Type: EventEnabled, Name: name})
+
+	return nil
+}
+
+func (r *FsRegistry) enablePlugin(name string) error {
+	release, err := r.lock(context.Background())
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	p, err := r.GetPlugin(name)
+	if err != nil {
+		return err
+	}
+
+	if p != nil {
+		if err := r.checkCompatibility(context.Background(), p, "could not enable plugin"); err != nil {
+			return err
+		}
+
+		if err := r.checkRequirements(context.Background(), p); err != nil {
+			return err
+		}
+	}
+
 	return r.config.EnablePlugin(name)
 }
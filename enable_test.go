@@ -4,30 +4,97 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 
+	"github.com/nhatthm/plugin-registry/config"
 	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	"github.com/nhatthm/plugin-registry/plugin"
 )
 
 func TestRegistry_Enable(t *testing.T) {
 	t.Parallel()
 
+	configWith := func(lib plugin.Plugin, constraint string) config.Configuration {
+		app := plugin.Plugin{
+			Name:     "my-plugin",
+			Version:  "v1.0.0",
+			Requires: plugin.Requirements{{Name: "lib", Version: plugin.MustParseConstraint(constraint)}},
+		}
+
+		plugins := plugin.Plugins{"my-plugin": app}
+
+		if lib.Name != "" {
+			plugins[lib.Name] = lib
+		}
+
+		return config.Configuration{Plugins: plugins}
+	}
+
 	testCases := []struct {
 		scenario      string
 		mockConfig    configuratorMock.Mocker
 		expectedError string
 	}{
+		{
+			scenario: "could not get config",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, errors.New("get config error"))
+			}),
+			expectedError: "get config error",
+		},
 		{
 			scenario: "failure",
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("EnablePlugin", "my-plugin").
 					Return(errors.New("disable error"))
 			}),
 			expectedError: "disable error",
 		},
+		{
+			scenario: "required plugin is not installed",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith(plugin.Plugin{}, ""), nil)
+			}),
+			expectedError: "could not enable plugin: required plugin is not installed",
+		},
+		{
+			scenario: "required plugin is disabled",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith(plugin.Plugin{Name: "lib", Version: "v1.0.0"}, ""), nil)
+			}),
+			expectedError: "could not enable plugin: required plugin is disabled",
+		},
+		{
+			scenario: "required plugin does not satisfy the constraint",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith(plugin.Plugin{Name: "lib", Version: "v1.0.0", Enabled: true}, "^2"), nil)
+			}),
+			expectedError: "could not enable plugin: plugin dependency conflict",
+		},
+		{
+			scenario: "required plugin is enabled",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith(plugin.Plugin{Name: "lib", Version: "v1.2.0", Enabled: true}, "^1"), nil)
+
+				c.On("EnablePlugin", "my-plugin").
+					Return(nil)
+			}),
+		},
 		{
 			scenario: "success",
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("EnablePlugin", "my-plugin").
 					Return(nil)
 			}),
@@ -39,7 +106,7 @@ func TestRegistry_Enable(t *testing.T) {
 		t.Run(tc.scenario, func(t *testing.T) {
 			t.Parallel()
 
-			r, err := NewRegistry("/tmp", WithConfigurator(tc.mockConfig(t)))
+			r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()), WithConfigurator(tc.mockConfig(t)))
 			require.NoError(t, err)
 
 			err = r.Enable("my-plugin")
@@ -0,0 +1,69 @@
+package registry
+
+// EventType is the type of a change of the registry.
+type EventType string
+
+const (
+	// EventInstalled is emitted after a plugin is installed.
+	EventInstalled EventType = "installed"
+	// EventUpgraded is emitted after an installed plugin is replaced by another version or revision.
+	EventUpgraded EventType = "upgraded"
+	// EventEnabled is emitted after a plugin is enabled.
+	EventEnabled EventType = "enabled"
+	// EventDisabled is emitted after a plugin is disabled.
+	EventDisabled EventType = "disabled"
+	// EventUninstalled is emitted after a plugin is uninstalled.
+	EventUninstalled EventType = "uninstalled"
+)
+
+// Event is a change of the registry.
+type Event struct {
+	Type EventType
+	Name string
+}
+
+// Listener is notified of the changes of the registry, after the registry is unlocked.
+type Listener func(e Event)
+
+type subscription struct {
+	listener Listener
+}
+
+// Subscribe registers a listener for the changes of the registry, the returned function unregisters it.
+func (r *FsRegistry) Subscribe(l Listener) func() {
+	s := &subscription{listener: l}
+
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+
+	r.subscriptions = append(r.subscriptions, s)
+
+	return func() {
+		r.subscriptionsMu.Lock()
+		defer r.subscriptionsMu.Unlock()
+
+		for i, v := range r.subscriptions {
+			if v == s {
+				r.subscriptions = append(r.subscriptions[:i:i], r.subscriptions[i+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+func (r *FsRegistry) notify(e Event) {
+	r.subscriptionsMu.Lock()
+	subscriptions := r.subscriptions
+	r.subscriptionsMu.Unlock()
+
+	for _, s := range subscriptions {
+		s.listener(e)
+	}
+}
+
+func (r *FsRegistry) notifyAll(events []Event) {
+	for _, e := range events {
+		r.notify(e)
+	}
+}
@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/installer"
+	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestFsRegistry_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	c := configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+		c.On("Config").Return(config.Configuration{}, nil)
+		c.On("EnablePlugin", "my-plugin").Return(nil)
+		c.On("EnablePlugin", "other-plugin").Return(errors.New("enable error"))
+		c.On("DisablePlugin", "my-plugin").Return(nil)
+		c.On("RemovePlugin", "my-plugin").Return(nil)
+	})(t)
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()), WithConfigurator(c))
+	require.NoError(t, err)
+
+	var events, unsubscribedEvents []Event
+
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	unsubscribe := r.Subscribe(func(e Event) {
+		unsubscribedEvents = append(unsubscribedEvents, e)
+	})
+
+	require.NoError(t, r.Enable("my-plugin"))
+	require.Error(t, r.Enable("other-plugin"))
+
+	unsubscribe()
+
+	require.NoError(t, r.ForceDisable("my-plugin"))
+	require.NoError(t, r.ForceUninstall("my-plugin"))
+
+	expected := []Event{
+		{Type: EventEnabled, Name: "my-plugin"},
+		{Type: EventDisabled, Name: "my-plugin"},
+		{Type: EventUninstalled, Name: "my-plugin"},
+	}
+
+	assert.Equal(t, expected, events)
+	assert.Equal(t, expected[:1], unsubscribedEvents)
+}
+
+func TestFsRegistry_Subscribe_InstallAndUpgrade(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+	app := func(version string) *plugin.Plugin {
+		return &plugin.Plugin{
+			Name:     "app",
+			Version:  version,
+			Enabled:  true,
+			Requires: plugin.Requirements{{Name: "lib", Source: source + "/lib"}},
+		}
+	}
+
+	installerMock.RegisterPlugin(source+"/app", app("v1.0.0"))
+	installerMock.RegisterPlugin(source+"/lib", &plugin.Plugin{Name: "lib", Version: "v1.0.0", Enabled: true})
+	installerMock.RegisterPlugin(source+"/other", &plugin.Plugin{Name: "other", Version: "v1.0.0", Enabled: true})
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	var events []Event
+
+	r.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	require.NoError(t, r.Install(context.Background(), source+"/app"))
+	require.Error(t, r.InstallRequest(context.Background(), installer.Request{Source: source + "/other", Name: "another"}))
+
+	// The same version is not an upgrade.
+	require.NoError(t, r.Upgrade(context.Background(), "app"))
+
+	installerMock.RegisterPlugin(source+"/app", app("v1.1.0"))
+
+	require.NoError(t, r.Upgrade(context.Background(), "app"))
+
+	// Installing an installed plugin replaces it.
+	require.NoError(t, r.Install(context.Background(), source+"/lib"))
+
+	expected := []Event{
+		{Type: EventInstalled, Name: "lib"},
+		{Type: EventInstalled, Name: "app"},
+		{Type: EventUpgraded, Name: "app"},
+		{Type: EventUpgraded, Name: "lib"},
+	}
+
+	assert.Equal(t, expected, events)
+}
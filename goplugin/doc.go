@@ -0,0 +1,3 @@
+// Package goplugin provides functionalities for loading the installed plugins that are built as Go plugins, with
+// "go build -buildmode=plugin".
+package goplugin
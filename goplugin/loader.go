@@ -0,0 +1,188 @@
+package goplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	stdplugin "plugin"
+	"reflect"
+	"strings"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// DefaultSymbol is the symbol that a Go plugin exports by default.
+const DefaultSymbol = "Plugin"
+
+var (
+	// ErrUnsupported indicates that Go plugins are not supported on the platform, or the host is built without cgo.
+	ErrUnsupported = errors.New("go plugins are not supported")
+	// ErrABIMismatch indicates that the plugin is built with another version of Go or of a shared package.
+	ErrABIMismatch = errors.New("go plugin is built with a different version of go or packages")
+	// ErrSymbolNotFound indicates that the plugin does not export the symbol.
+	ErrSymbolNotFound = errors.New("go plugin symbol not found")
+	// ErrInterfaceMismatch indicates that the symbol does not implement the interface of the host.
+	ErrInterfaceMismatch = errors.New("go plugin symbol does not implement the interface")
+	// ErrInvalidTarget indicates that the target is not a non-nil pointer to an interface.
+	ErrInvalidTarget = errors.New("target must be a non-nil pointer to an interface")
+)
+
+// Option configures Loader.
+type Option func(l *Loader)
+
+type lookupFunc func(symbol string) (interface{}, error)
+
+type openFunc func(path string) (lookupFunc, error)
+
+// Loader loads the Go plugins installed in a registry directory.
+type Loader struct {
+	path        string
+	symbol      string
+	hostVersion *plugin.Version
+
+	open openFunc
+}
+
+// Path returns the absolute path of the runtime artifact of the plugin.
+func (l *Loader) Path(p plugin.Plugin) (string, error) {
+	pluginDir, err := filepath.Abs(filepath.Join(l.path, p.Name))
+	if err != nil {
+		return "", err
+	}
+
+	path, err := p.RuntimeArtifactPath(pluginDir)
+	if err != nil {
+		return "", ctxd.WrapError(context.Background(), err, "could not load plugin", "name", p.Name)
+	}
+
+	return path, nil
+}
+
+// Load opens the runtime artifact of the plugin, looks up the symbol and stores it in the target, that must be a
+// pointer to an interface, like errors.As(). If the symbol is a variable, the variable or its pointer is stored,
+// whichever implements the interface.
+//
+//	var greeter Greeter
+//
+//	err := loader.Load(p, &greeter)
+func (l *Loader) Load(p plugin.Plugin, target interface{}) error {
+	ctx := context.Background()
+
+	val := reflect.ValueOf(target)
+	if !val.IsValid() || val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Interface {
+		return ErrInvalidTarget
+	}
+
+	if l.hostVersion != nil {
+		if err := p.CheckCompatibility(*l.hostVersion); err != nil {
+			return ctxd.WrapError(ctx, err, "could not load plugin", "name", p.Name)
+		}
+	}
+
+	path, err := l.Path(p)
+	if err != nil {
+		return err
+	}
+
+	lookup, err := l.open(path)
+	if err != nil {
+		return ctxd.WrapError(ctx, openError(err), "could not load plugin", "name", p.Name, "path", path)
+	}
+
+	sym, err := lookup(l.symbol)
+	if err != nil {
+		return ctxd.WrapError(ctx, ErrSymbolNotFound, "could not load plugin", "name", p.Name, "symbol", l.symbol)
+	}
+
+	iface := val.Elem().Type()
+
+	for _, v := range symbolValues(sym) {
+		if v.Type().Implements(iface) {
+			val.Elem().Set(v)
+
+			return nil
+		}
+	}
+
+	return ctxd.WrapError(ctx, ErrInterfaceMismatch, "could not load plugin",
+		"name", p.Name,
+		"symbol", l.symbol,
+		"type", fmt.Sprintf("%T", sym),
+		"interface", iface.String(),
+	)
+}
+
+// New creates a new loader for the Go plugins installed in the registry directory.
+func New(path string, options ...Option) *Loader {
+	l := &Loader{
+		path:   filepath.Clean(path),
+		symbol: DefaultSymbol,
+		open:   openPlugin,
+	}
+
+	for _, o := range options {
+		o(l)
+	}
+
+	return l
+}
+
+// WithSymbol sets the symbol to look up, the default is DefaultSymbol.
+func WithSymbol(symbol string) Option {
+	return func(l *Loader) {
+		l.symbol = symbol
+	}
+}
+
+// WithHostVersion sets the version of the host. Once set, the plugins that do not support it are not loaded.
+func WithHostVersion(v plugin.Version) Option {
+	return func(l *Loader) {
+		l.hostVersion = &v
+	}
+}
+
+func openPlugin(path string) (lookupFunc, error) {
+	p, err := stdplugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(symbol string) (interface{}, error) {
+		return p.Lookup(symbol)
+	}, nil
+}
+
+// openError translates the errors of the plugin package, which are not typed.
+func openError(err error) error {
+	msg := err.Error()
+
+	switch {
+	case strings.Contains(msg, "plugin was built with a different version"):
+		return fmt.Errorf("%w: %s", ErrABIMismatch, msg)
+
+	case strings.Contains(msg, "not implemented"):
+		return fmt.Errorf("%w: %s", ErrUnsupported, msg)
+	}
+
+	return err
+}
+
+// symbolValues returns the candidates to store in the target. A variable is exported as a pointer to it, so both the
+// pointer and the variable are candidates.
+func symbolValues(sym interface{}) []reflect.Value {
+	v := reflect.ValueOf(sym)
+	if !v.IsValid() {
+		return nil
+	}
+
+	result := []reflect.Value{v}
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		result = append(result, v.Elem())
+	}
+
+	return result
+}
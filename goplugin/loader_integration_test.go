@@ -0,0 +1,96 @@
+package goplugin_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/goplugin"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const greeterSource = `package main
+
+type greeter struct{}
+
+func (greeter) Greet(name string) string {
+	return "hello " + name
+}
+
+// Plugin is the exported symbol.
+var Plugin greeter
+
+func main() {}
+`
+
+type greeter interface {
+	Greet(name string) string
+}
+
+func TestIntegrationLoader_Load(t *testing.T) {
+	t.Parallel()
+
+	if testing.Short() {
+		t.Skip("building a go plugin is slow")
+	}
+
+	if !pluginSupported(t) {
+		t.Skip("go plugins are not supported")
+	}
+
+	registryDir := t.TempDir()
+	srcDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "go.mod"), []byte("module greeter\n\ngo 1.17\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "main.go"), []byte(greeterSource), 0o644))
+
+	out := filepath.Join(registryDir, "greeter", "greeter.so")
+
+	args := []string{"build", "-buildmode=plugin", "-o", out}
+
+	if raceEnabled {
+		args = append(args, "-race")
+	}
+
+	cmd := exec.Command("go", append(args, ".")...) //nolint: gosec
+	cmd.Dir = srcDir
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, "could not build go plugin: %s", output)
+
+	p := plugin.Plugin{
+		Name:    "greeter",
+		Version: "v1.0.0",
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}.so"},
+		},
+	}
+
+	var g greeter
+
+	err = goplugin.New(registryDir).Load(p, &g)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", g.Greet("world"))
+}
+
+// pluginSupported checks whether go plugins can be built and loaded, they need cgo and one of the supported platforms.
+func pluginSupported(t *testing.T) bool {
+	t.Helper()
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		return false
+	}
+
+	out, err := exec.Command("go", "env", "CGO_ENABLED").Output()
+	require.NoError(t, err)
+
+	return strings.TrimSpace(string(out)) == "1"
+}
@@ -0,0 +1,186 @@
+package goplugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type helloGreeter struct {
+	greeting string
+}
+
+func (g helloGreeter) Greet(name string) string {
+	return g.greeting + " " + name
+}
+
+type pointerGreeter struct{}
+
+func (g *pointerGreeter) Greet(name string) string {
+	return "hi " + name
+}
+
+func newPlugin() plugin.Plugin {
+	return plugin.Plugin{
+		Name:    "my-plugin",
+		Version: "v1.0.0",
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}-${version}.so"},
+		},
+	}
+}
+
+func mockOpen(expectedPath string, symbols map[string]interface{}, err error) openFunc {
+	return func(path string) (lookupFunc, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		if path != expectedPath {
+			return nil, errors.New("unexpected path: " + path)
+		}
+
+		return func(symbol string) (interface{}, error) {
+			sym, ok := symbols[symbol]
+			if !ok {
+				return nil, errors.New("plugin: symbol " + symbol + " not found")
+			}
+
+			return sym, nil
+		}, nil
+	}
+}
+
+func TestLoader_Load(t *testing.T) {
+	t.Parallel()
+
+	l := New("/tmp")
+
+	path, err := l.Path(newPlugin())
+	require.NoError(t, err)
+
+	assert.Equal(t, "/tmp/my-plugin/my-plugin-v1.0.0.so", path)
+
+	hello := helloGreeter{greeting: "hello"}
+
+	testCases := []struct {
+		scenario       string
+		options        []Option
+		open           openFunc
+		expectedResult string
+		expectedError  error
+	}{
+		{
+			scenario:      "unsupported",
+			open:          mockOpen(path, nil, errors.New("plugin: not implemented")),
+			expectedError: ErrUnsupported,
+		},
+		{
+			scenario: "abi mismatch",
+			open: mockOpen(path, nil,
+				errors.New(`plugin.Open("my-plugin"): plugin was built with a different version of package runtime`),
+			),
+			expectedError: ErrABIMismatch,
+		},
+		{
+			scenario:      "symbol not found",
+			open:          mockOpen(path, map[string]interface{}{"Other": hello}, nil),
+			expectedError: ErrSymbolNotFound,
+		},
+		{
+			scenario:      "interface mismatch",
+			open:          mockOpen(path, map[string]interface{}{"Plugin": "hello"}, nil),
+			expectedError: ErrInterfaceMismatch,
+		},
+		{
+			scenario:      "incompatible host",
+			options:       []Option{WithHostVersion(plugin.MustParseVersion("2.0.0"))},
+			open:          mockOpen(path, map[string]interface{}{"Plugin": hello}, nil),
+			expectedError: plugin.ErrIncompatible,
+		},
+		{
+			scenario:       "value",
+			open:           mockOpen(path, map[string]interface{}{"Plugin": hello}, nil),
+			expectedResult: "hello world",
+		},
+		{
+			scenario:       "pointer to variable",
+			open:           mockOpen(path, map[string]interface{}{"Plugin": &hello}, nil),
+			expectedResult: "hello world",
+		},
+		{
+			scenario:       "pointer receiver",
+			open:           mockOpen(path, map[string]interface{}{"Plugin": &pointerGreeter{}}, nil),
+			expectedResult: "hi world",
+		},
+		{
+			scenario:       "custom symbol",
+			options:        []Option{WithSymbol("Greeter")},
+			open:           mockOpen(path, map[string]interface{}{"Greeter": hello}, nil),
+			expectedResult: "hello world",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p := newPlugin()
+			p.Compatibility.Host = plugin.MustParseConstraint("^1")
+
+			l := New("/tmp", tc.options...)
+			l.open = tc.open
+
+			var g greeter
+
+			err := l.Load(p, &g)
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, g)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, g.Greet("world"))
+		})
+	}
+}
+
+func TestLoader_Load_InvalidTarget(t *testing.T) {
+	t.Parallel()
+
+	l := New("/tmp")
+	l.open = mockOpen("", nil, errors.New("should not open"))
+
+	var g greeter
+
+	var s string
+
+	for _, target := range []interface{}{nil, g, &s, (*greeter)(nil)} {
+		err := l.Load(newPlugin(), target)
+
+		require.ErrorIs(t, err, ErrInvalidTarget)
+	}
+}
+
+func TestLoader_Path_Invalid(t *testing.T) {
+	t.Parallel()
+
+	p := newPlugin()
+	p.Artifacts[plugin.RuntimeArtifactIdentifier()] = plugin.Artifact{File: "../other-plugin/other.so"}
+
+	_, err := New("/tmp").Path(p)
+
+	require.ErrorIs(t, err, plugin.ErrInvalidArtifactPath)
+}
@@ -0,0 +1,7 @@
+//go:build !race
+// +build !race
+
+package goplugin_test
+
+// raceEnabled is true when the tests are built with the race detector, the go plugins must be built the same way.
+const raceEnabled = false
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/index"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// resolveIndex resolves a source in the form "name" or "name@version" to where to download the plugin, if the plugin
+// is in the index. The version in the source and the version of the request must both be satisfied. The other sources
+// are kept as is.
+func (r *FsRegistry) resolveIndex(ctx context.Context, req installer.Request) (installer.Request, error) {
+	if r.index == nil {
+		return req, nil
+	}
+
+	name, version := req.Source, ""
+
+	if pos := strings.LastIndex(req.Source, "@"); pos > 0 {
+		name, version = req.Source[:pos], req.Source[pos+1:]
+	}
+
+	if _, ok := r.index.Find(name); !ok {
+		return req, nil
+	}
+
+	if version != "" {
+		c, err := plugin.ParseConstraint(version)
+		if err != nil {
+			return req, ctxd.WrapError(ctx, err, "could not install plugin", "source", req.Source)
+		}
+
+		req.Version = req.Version.And(c)
+	}
+
+	_, d, err := r.index.Resolve(name, req.Version)
+	if err != nil {
+		return req, err
+	}
+
+	if req.Name == "" {
+		req.Name = name
+	}
+
+	if req.SourceChecksum == "" {
+		req.SourceChecksum = d.Checksum
+	}
+
+	req.Source = d.URL
+
+	return req, nil
+}
+
+// WithIndex sets the index of plugins. Once set, the plugins in the index can be installed by their names, with an
+// optional version constraint, such as "my-plugin" or "my-plugin@^1.2".
+func WithIndex(idx *index.Index) Option {
+	return func(r *FsRegistry) {
+		r.index = idx
+	}
+}
@@ -0,0 +1,3 @@
+// Package index provides a catalog of plugins, with their versions and where to download them, so that the plugins can
+// be installed by their names.
+package index
@@ -0,0 +1,238 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+var (
+	// ErrPluginNotFound indicates that the plugin is not in the index.
+	ErrPluginNotFound = errors.New("plugin not found in index")
+	// ErrVersionNotFound indicates that no version of the plugin satisfies the constraint.
+	ErrVersionNotFound = errors.New("no version satisfies the constraint")
+	// ErrArtifactNotFound indicates that the version of the plugin has no artifact for the platform.
+	ErrArtifactNotFound = errors.New("no artifact for the platform")
+	// ErrUnexpectedStatus indicates that the http endpoint of the index did not respond with 200 OK.
+	ErrUnexpectedStatus = errors.New("unexpected status")
+)
+
+// Index is a catalog of plugins. It is written in YAML or JSON, for example:
+//
+//	plugins:
+//	    - name: my-plugin
+//	      description: My plugin
+//	      tags: [tools]
+//	      versions:
+//	          - version: v1.2.0
+//	            artifacts:
+//	                linux/amd64:
+//	                    url: https://example.org/my-plugin-1.2.0-linux-amd64.tar.gz
+//	                    checksum: sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824
+type Index struct {
+	Plugins []Entry `yaml:"plugins"`
+}
+
+// Entry is a plugin in the index.
+type Entry struct {
+	Name        string      `yaml:"name"`
+	Description string      `yaml:"description"`
+	Tags        plugin.Tags `yaml:"tags"`
+	Versions    []Release   `yaml:"versions"`
+}
+
+// Release is a version of a plugin in the index.
+type Release struct {
+	Version   string                                 `yaml:"version"`
+	Artifacts map[plugin.ArtifactIdentifier]Download `yaml:"artifacts"`
+}
+
+// Download is where to download an artifact of a plugin.
+type Download struct {
+	URL string `yaml:"url"`
+	// Checksum is the checksum of the downloaded file, prefixed by the algorithm, such as "sha256:<hex>". It is
+	// optional.
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// Find finds a plugin by name.
+func (idx *Index) Find(name string) (Entry, bool) {
+	for _, e := range idx.Plugins {
+		if e.Name == name {
+			return e, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// Search returns the plugins whose name, description or tags contain the query, case-insensitively, sorted by name.
+// An empty query returns all the plugins.
+func (idx *Index) Search(query string) []Entry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	result := make([]Entry, 0, len(idx.Plugins))
+
+	for _, e := range idx.Plugins {
+		if e.matches(query) {
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Resolve returns the latest version of the plugin that satisfies the constraint and has an artifact for the current
+// platform, along with where to download the artifact.
+func (idx *Index) Resolve(name string, c plugin.Constraint) (Release, Download, error) {
+	ctx := context.Background()
+
+	e, ok := idx.Find(name)
+	if !ok {
+		return Release{}, Download{}, ctxd.WrapError(ctx, ErrPluginNotFound, "could not resolve plugin", "name", name)
+	}
+
+	r, err := e.Latest(c)
+	if err != nil {
+		return Release{}, Download{}, ctxd.WrapError(ctx, err, "could not resolve plugin",
+			"name", name,
+			"constraint", c.String(),
+		)
+	}
+
+	d, ok := r.RuntimeDownload()
+	if !ok {
+		return Release{}, Download{}, ctxd.WrapError(ctx, ErrArtifactNotFound, "could not resolve plugin",
+			"name", name,
+			"version", r.Version,
+			"platform", plugin.RuntimeArtifactIdentifier().String(),
+		)
+	}
+
+	return r, d, nil
+}
+
+func (e Entry) matches(query string) bool {
+	if query == "" ||
+		strings.Contains(strings.ToLower(e.Name), query) ||
+		strings.Contains(strings.ToLower(e.Description), query) {
+		return true
+	}
+
+	for _, t := range e.Tags {
+		if strings.Contains(strings.ToLower(t), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Latest returns the latest version that satisfies the constraint. The invalid versions are ignored.
+func (e Entry) Latest(c plugin.Constraint) (Release, error) {
+	var (
+		latest  Release
+		version *plugin.Version
+	)
+
+	for _, r := range e.Versions {
+		v, err := plugin.ParseVersion(r.Version)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+
+		if version == nil || v.GreaterThan(*version) {
+			latest, version = r, &v
+		}
+	}
+
+	if version == nil {
+		return Release{}, ErrVersionNotFound
+	}
+
+	return latest, nil
+}
+
+// RuntimeDownload returns where to download the artifact of current arch, or of current os if there is none.
+func (r Release) RuntimeDownload() (Download, bool) {
+	if d, ok := r.Artifacts[plugin.RuntimeArtifactIdentifier()]; ok {
+		return d, true
+	}
+
+	d, ok := r.Artifacts[plugin.RuntimeArtifactIdentifierWithoutArch()]
+
+	return d, ok
+}
+
+// Parse parses an index in YAML or JSON.
+func Parse(r io.Reader) (*Index, error) {
+	var idx Index
+
+	if err := yaml.NewDecoder(r).Decode(&idx); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return &idx, nil
+}
+
+// Load loads an index from a file.
+func Load(fs afero.Fs, path string) (*Index, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, ctxd.WrapError(context.Background(), err, "could not load index", "path", path)
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	idx, err := Parse(f)
+	if err != nil {
+		return nil, ctxd.WrapError(context.Background(), err, "could not load index", "path", path)
+	}
+
+	return idx, nil
+}
+
+// Fetch fetches an index from a http endpoint. If the client is nil, http.DefaultClient is used.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Index, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not fetch index", "url", url)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not fetch index", "url", url)
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ctxd.WrapError(ctx, ErrUnexpectedStatus, "could not fetch index",
+			"url", url,
+			"status", resp.StatusCode,
+		)
+	}
+
+	idx, err := Parse(resp.Body)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not fetch index", "url", url)
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,254 @@
+package index_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/index"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const indexFile = "../resources/fixtures/index/index.yaml"
+
+func loadIndex(t *testing.T) *index.Index {
+	t.Helper()
+
+	idx, err := index.Load(afero.NewOsFs(), indexFile)
+	require.NoError(t, err)
+
+	return idx
+}
+
+func names(entries []index.Entry) []string {
+	result := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		result = append(result, e.Name)
+	}
+
+	return result
+}
+
+func TestParse_JSON(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.Parse(strings.NewReader(`{
+    "plugins": [
+        {
+            "name": "my-plugin",
+            "tags": ["tools"],
+            "versions": [
+                {
+                    "version": "v1.0.0",
+                    "artifacts": {"linux/amd64": {"url": "https://example.org/my-plugin.tar.gz"}}
+                }
+            ]
+        }
+    ]
+}`))
+	require.NoError(t, err)
+
+	expected := &index.Index{Plugins: []index.Entry{{
+		Name: "my-plugin",
+		Tags: plugin.Tags{"tools"},
+		Versions: []index.Release{{
+			Version: "v1.0.0",
+			Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.NewArtifactIdentifier("linux", "amd64"): {URL: "https://example.org/my-plugin.tar.gz"},
+			},
+		}},
+	}}}
+
+	assert.Equal(t, expected, idx)
+}
+
+func TestParse_Empty(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.Parse(strings.NewReader(""))
+	require.NoError(t, err)
+
+	assert.Empty(t, idx.Plugins)
+}
+
+func TestLoad_NotFound(t *testing.T) {
+	t.Parallel()
+
+	idx, err := index.Load(afero.NewMemMapFs(), "index.yaml")
+
+	assert.Nil(t, idx)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestIndex_Search(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		query    string
+		expected []string
+	}{
+		{
+			scenario: "all",
+			expected: []string{"another-plugin", "my-plugin"},
+		},
+		{
+			scenario: "name",
+			query:    "My-",
+			expected: []string{"my-plugin"},
+		},
+		{
+			scenario: "description",
+			query:    "awesome",
+			expected: []string{"my-plugin"},
+		},
+		{
+			scenario: "tag",
+			query:    "lint",
+			expected: []string{"another-plugin"},
+		},
+		{
+			scenario: "no match",
+			query:    "unknown",
+			expected: []string{},
+		},
+	}
+
+	idx := loadIndex(t)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, names(idx.Search(tc.query)))
+		})
+	}
+}
+
+func TestIndex_Resolve(t *testing.T) {
+	t.Parallel()
+
+	idx := &index.Index{Plugins: []index.Entry{{
+		Name: "my-plugin",
+		Versions: []index.Release{
+			{Version: "v1.0.0", Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.RuntimeArtifactIdentifierWithoutArch(): {URL: "https://example.org/1.0.0"},
+			}},
+			{Version: "v1.2.0", Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.RuntimeArtifactIdentifier(): {URL: "https://example.org/1.2.0", Checksum: "sha256:abc"},
+			}},
+			{Version: "invalid", Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.RuntimeArtifactIdentifier(): {URL: "https://example.org/invalid"},
+			}},
+			{Version: "v1.3.0", Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.NewArtifactIdentifier("plan9", "arm"): {URL: "https://example.org/1.3.0"},
+			}},
+			{Version: "v1.3.0-rc.1", Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.RuntimeArtifactIdentifier(): {URL: "https://example.org/1.3.0-rc.1"},
+			}},
+		},
+	}}}
+
+	testCases := []struct {
+		scenario         string
+		name             string
+		constraint       string
+		expectedVersion  string
+		expectedDownload index.Download
+		expectedError    error
+	}{
+		{
+			scenario:         "latest",
+			name:             "my-plugin",
+			constraint:       "<1.3",
+			expectedVersion:  "v1.2.0",
+			expectedDownload: index.Download{URL: "https://example.org/1.2.0", Checksum: "sha256:abc"},
+		},
+		{
+			scenario:         "pre-release",
+			name:             "my-plugin",
+			constraint:       ">=1.3.0-rc.1 <1.3.0",
+			expectedVersion:  "v1.3.0-rc.1",
+			expectedDownload: index.Download{URL: "https://example.org/1.3.0-rc.1"},
+		},
+		{
+			scenario:         "artifact without arch",
+			name:             "my-plugin",
+			constraint:       "~1.0",
+			expectedVersion:  "v1.0.0",
+			expectedDownload: index.Download{URL: "https://example.org/1.0.0"},
+		},
+		{
+			scenario:      "no artifact for the platform",
+			name:          "my-plugin",
+			expectedError: index.ErrArtifactNotFound,
+		},
+		{
+			scenario:      "no version",
+			name:          "my-plugin",
+			constraint:    "^2",
+			expectedError: index.ErrVersionNotFound,
+		},
+		{
+			scenario:      "unknown plugin",
+			name:          "unknown",
+			expectedError: index.ErrPluginNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			r, d, err := idx.Resolve(tc.name, plugin.MustParseConstraint(tc.constraint))
+
+			assert.Equal(t, tc.expectedVersion, r.Version)
+			assert.Equal(t, tc.expectedDownload, d)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(indexFile)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(data) //nolint: errcheck
+	}))
+
+	t.Cleanup(srv.Close)
+
+	idx, err := index.Fetch(context.Background(), srv.Client(), srv.URL+"/index.yaml")
+	require.NoError(t, err)
+
+	assert.Equal(t, loadIndex(t), idx)
+
+	idx, err = index.Fetch(context.Background(), nil, srv.URL+"/unknown.yaml")
+
+	assert.Nil(t, idx)
+	require.ErrorIs(t, err, index.ErrUnexpectedStatus)
+}
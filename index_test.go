@@ -0,0 +1,151 @@
+package registry_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/index"
+	"github.com/nhatthm/plugin-registry/installer"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// newIndex registers an installer for each download of the index, and returns the index.
+func newIndex(t *testing.T) *index.Index {
+	t.Helper()
+
+	baseURL := "https://" + strings.ToLower(t.Name()) + ".example.org/"
+	versions := []string{"v1.0.0", "v1.2.0", "v2.0.0"}
+	releases := make([]index.Release, 0, len(versions))
+
+	for _, version := range versions {
+		installerMock.RegisterPlugin(baseURL+version, &plugin.Plugin{Name: "my-plugin", Version: version})
+
+		releases = append(releases, index.Release{
+			Version: version,
+			Artifacts: map[plugin.ArtifactIdentifier]index.Download{
+				plugin.RuntimeArtifactIdentifier(): {URL: baseURL + version},
+			},
+		})
+	}
+
+	return &index.Index{Plugins: []index.Entry{{Name: "my-plugin", Versions: releases}}}
+}
+
+func TestRegistry_Install_Index(t *testing.T) {
+	t.Parallel()
+
+	idx := newIndex(t)
+
+	testCases := []struct {
+		scenario        string
+		source          string
+		version         string
+		expectedVersion string
+		expectedError   string
+	}{
+		{
+			scenario:        "latest",
+			source:          "my-plugin",
+			expectedVersion: "v2.0.0",
+		},
+		{
+			scenario:        "with version",
+			source:          "my-plugin@1.0.0",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			scenario:        "with constraint",
+			source:          "my-plugin@^1",
+			expectedVersion: "v1.2.0",
+		},
+		{
+			scenario:        "with constraint and version",
+			source:          "my-plugin@^1",
+			version:         "=1.0.0",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			scenario:      "conflicting constraint and version",
+			source:        "my-plugin@^2",
+			version:       "=1.0.0",
+			expectedError: "could not resolve plugin: no version satisfies the constraint",
+		},
+		{
+			scenario:      "no version",
+			source:        "my-plugin@^3",
+			expectedError: "could not resolve plugin: no version satisfies the constraint",
+		},
+		{
+			scenario:      "invalid constraint",
+			source:        "my-plugin@foo",
+			expectedError: "could not install plugin: invalid version constraint",
+		},
+		{
+			scenario:      "not in index",
+			source:        "other-plugin@1.0.0",
+			expectedError: "no supported installer",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()), registry.WithIndex(idx))
+			require.NoError(t, err)
+
+			err = r.InstallRequest(context.Background(), installer.Request{
+				Source:  tc.source,
+				Version: plugin.MustParseConstraint(tc.version),
+			})
+
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			p, err := r.GetPlugin("my-plugin")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedVersion, p.Version)
+			assert.Equal(t, tc.source, p.Source)
+		})
+	}
+}
+
+func TestRegistry_Plan_Index(t *testing.T) {
+	t.Parallel()
+
+	idx := newIndex(t)
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()), registry.WithIndex(idx))
+	require.NoError(t, err)
+
+	desired := registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "my-plugin", Source: "my-plugin@^1", Enabled: true},
+	}}
+
+	plan, err := r.Plan(context.Background(), desired)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Apply(context.Background(), plan))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.2.0", p.Version)
+
+	_, err = r.Plan(context.Background(), registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "other-plugin", Source: "other-plugin@^1"},
+	}})
+	require.EqualError(t, err, "could not plan plugin: no supported installer")
+}
@@ -2,50 +2,89 @@ package registry
 
 import (
 	"context"
+	"errors"
 
-	fsCtx "github.com/nhatthm/plugin-registry/context"
 	"github.com/nhatthm/plugin-registry/installer"
-	"github.com/nhatthm/plugin-registry/plugin"
 )
 
-func (r *FsRegistry) makeInstaller(ctx context.Context, src string) (installer.Installer, error) {
-	i, err := installer.Find(fsCtx.WithFs(ctx, r.fs), src)
-	if err != nil {
-		return nil, err
-	}
+var (
+	// ErrPluginMismatch indicates that the installed plugin is not the requested one.
+	ErrPluginMismatch = errors.New("plugin mismatch")
+	// ErrVersionNotSatisfied indicates that the version of the installed plugin does not satisfy the constraint.
+	ErrVersionNotSatisfied = errors.New("plugin version does not satisfy the constraint")
+)
 
-	return r.installer(i), nil
+// Install installs plugin from a url. The plugin is staged and validated before replacing the installed one, nothing
+// is changed if the installation fails.
+func (r *FsRegistry) Install(ctx context.Context, src string) error {
+	return r.InstallRequest(ctx, installer.Request{Source: src})
 }
 
-func (r *FsRegistry) installer(i installer.Installer) installer.CallbackInstaller {
-	return func(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
-		p, err := i.Install(ctx, dest, src)
-		if err != nil {
-			return nil, err
-		}
+// InstallRequest installs a plugin that matches the request. The request is passed to the installer, the plugin is
+// rejected if it is not the requested one or its version does not satisfy the constraint. The missing plugins that it
+// requires are installed first.
+func (r *FsRegistry) InstallRequest(ctx context.Context, req installer.Request) error {
+	var events []Event
 
-		oldPlugin, err := r.GetPlugin(p.Name)
-		if err != nil {
-			return nil, err
-		}
+	// The dependencies that are installed before a failure stay installed, their events are emitted too.
+	err := r.installPlugin(ctx, req, &events)
 
-		// Do not accidentally enable the disabled plugin.
-		if oldPlugin != nil {
-			p.Enabled = oldPlugin.Enabled
-		}
+	r.notifyAll(events)
 
-		return p, r.config.SetPlugin(*p)
+	return err
+}
+
+func (r *FsRegistry) installPlugin(ctx context.Context, req installer.Request, events *[]Event) error {
+	release, err := r.lock(ctx)
+	if err != nil {
+		return err
 	}
+
+	defer release()
+
+	r.removeStageDirs()
+
+	return r.install(ctx, req, nil, events)
 }
 
-// Install installs plugin from a url.
-func (r *FsRegistry) Install(ctx context.Context, src string) error {
-	i, err := r.makeInstaller(ctx, src)
+// install installs a plugin and its dependencies, the registry must be locked. The events are emitted by the caller
+// once the registry is unlocked.
+func (r *FsRegistry) install(ctx context.Context, req installer.Request, resolving []string, events *[]Event) error {
+	stageDir, p, err := r.stagePlugin(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	_, err = i.Install(ctx, r.path, src)
+	defer r.fs.RemoveAll(stageDir) //nolint: errcheck
 
-	return err
+	if err := r.resolveDependencies(ctx, p, resolving, events); err != nil {
+		return err
+	}
+
+	oldPlugin, err := r.GetPlugin(p.Name)
+	if err != nil {
+		return err
+	}
+
+	// Do not accidentally enable the disabled plugin.
+	if oldPlugin != nil {
+		p.Enabled = oldPlugin.Enabled
+	}
+
+	// Remember where the plugin comes from for upgrading.
+	p.Source = req.Source
+
+	if err := r.swapPlugin(stageDir, *p); err != nil {
+		return err
+	}
+
+	e := Event{Type: EventInstalled, Name: p.Name}
+
+	if oldPlugin != nil {
+		e.Type = EventUpgraded
+	}
+
+	*events = append(*events, e)
+
+	return nil
 }
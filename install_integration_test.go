@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -14,6 +15,7 @@ import (
 
 	registry "github.com/nhatthm/plugin-registry"
 	"github.com/nhatthm/plugin-registry/installer"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
 	"github.com/nhatthm/plugin-registry/plugin"
 )
 
@@ -33,7 +35,7 @@ func TestIntegrationFsRegistry_Install_NoConfigFile(t *testing.T) {
 	installer.Register(t.Name(), func(_ context.Context, source string) bool {
 		return source == t.Name()
 	}, func(afero.Fs) installer.Installer {
-		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+		return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
 			p := &plugin.Plugin{
 				Name:        "my-plugin",
 				URL:         "https://example.org",
@@ -49,7 +51,11 @@ func TestIntegrationFsRegistry_Install_NoConfigFile(t *testing.T) {
 				Tags: plugin.Tags{"tag1"},
 			}
 
-			return p, aferocopy.Copy(pluginDir, "resources/fixtures")
+			if err := aferocopy.Copy("resources/fixtures", filepath.Join(dest, p.Name)); err != nil {
+				return nil, err
+			}
+
+			return p, installerMock.InstallPlugin(afero.NewOsFs(), filepath.Join(dest, p.Name), p)
 		})
 	})
 
@@ -83,7 +89,8 @@ func TestIntegrationFsRegistry_Install_NoConfigFile(t *testing.T) {
                 file: my-plugin
         tags:
             - tag1
-`, runtime.GOOS, runtime.GOARCH)
+        source: %s
+`, runtime.GOOS, runtime.GOARCH, t.Name())
 	actual, err := afero.ReadFile(fs, configFile)
 	require.NoError(t, err)
 	assert.Equal(t, expected, string(actual))
@@ -107,7 +114,7 @@ func TestIntegrationFsRegistry_Install_HasConfigFile(t *testing.T) {
 	installer.Register(t.Name(), func(_ context.Context, source string) bool {
 		return source == t.Name()
 	}, func(afero.Fs) installer.Installer {
-		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+		return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
 			p := &plugin.Plugin{
 				Name:        "my-plugin",
 				URL:         "https://example.org",
@@ -123,7 +130,11 @@ func TestIntegrationFsRegistry_Install_HasConfigFile(t *testing.T) {
 				Tags: plugin.Tags{"tag1"},
 			}
 
-			return p, aferocopy.Copy(pluginDir, "resources/fixtures")
+			if err := aferocopy.Copy("resources/fixtures", filepath.Join(dest, p.Name)); err != nil {
+				return nil, err
+			}
+
+			return p, installerMock.InstallPlugin(afero.NewOsFs(), filepath.Join(dest, p.Name), p)
 		})
 	})
 
@@ -157,7 +168,8 @@ func TestIntegrationFsRegistry_Install_HasConfigFile(t *testing.T) {
                 file: my-plugin
         tags:
             - tag1
-`, runtime.GOOS, runtime.GOARCH)
+        source: %s
+`, runtime.GOOS, runtime.GOARCH, t.Name())
 	actual, err := afero.ReadFile(fs, configFile)
 	require.NoError(t, err)
 	assert.Equal(t, expected, string(actual))
@@ -195,7 +207,7 @@ func TestIntegrationFsRegistry_Install_HasDisabledPlugin(t *testing.T) {
 	installer.Register(t.Name(), func(_ context.Context, source string) bool {
 		return source == t.Name()
 	}, func(afero.Fs) installer.Installer {
-		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+		return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
 			p := &plugin.Plugin{
 				Name:        "my-plugin",
 				URL:         "https://example.org",
@@ -211,7 +223,11 @@ func TestIntegrationFsRegistry_Install_HasDisabledPlugin(t *testing.T) {
 				Tags: plugin.Tags{"tag1"},
 			}
 
-			return p, aferocopy.Copy(pluginDir, "resources/fixtures")
+			if err := aferocopy.Copy("resources/fixtures", filepath.Join(dest, p.Name)); err != nil {
+				return nil, err
+			}
+
+			return p, installerMock.InstallPlugin(afero.NewOsFs(), filepath.Join(dest, p.Name), p)
 		})
 	})
 
@@ -245,8 +261,132 @@ func TestIntegrationFsRegistry_Install_HasDisabledPlugin(t *testing.T) {
                 file: my-plugin
         tags:
             - tag1
-`, runtime.GOOS, runtime.GOARCH)
+        source: %s
+`, runtime.GOOS, runtime.GOARCH, t.Name())
 	actual, err := afero.ReadFile(fs, configFile)
 	require.NoError(t, err)
 	assert.Equal(t, expected, string(actual))
 }
+
+func TestIntegrationFsRegistry_Install_RollbackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	registryDir := t.TempDir()
+	configFile := filepath.Join(registryDir, "config.yaml")
+
+	fs := afero.NewOsFs()
+
+	// Register installer.
+	installer.Register(t.Name(), func(_ context.Context, source string) bool {
+		return source == t.Name()
+	}, func(afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
+			// The plugin is half-written, without metadata.
+			pluginDir := filepath.Join(dest, "my-plugin")
+
+			if err := aferocopy.Copy("resources/fixtures/my-plugin", pluginDir); err != nil {
+				return nil, err
+			}
+
+			return &plugin.Plugin{Name: "my-plugin"}, nil
+		})
+	})
+
+	// Install plugin.
+	r, err := registry.NewRegistry(registryDir)
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), t.Name())
+	require.ErrorContains(t, err, "could not read metadata")
+
+	// Verify result.
+	entries, err := afero.ReadDir(fs, registryDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	exists, err := afero.Exists(fs, configFile)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestIntegrationFsRegistry_Install_VerifyArtifact(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewOsFs()
+
+	checksum, err := plugin.ComputeChecksum(fs, "resources/fixtures/my-plugin/my-plugin", plugin.ChecksumSHA256)
+	require.NoError(t, err)
+
+	fi, err := fs.Stat("resources/fixtures/my-plugin/my-plugin")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		scenario      string
+		artifact      plugin.Artifact
+		expectedError string
+	}{
+		{
+			scenario:      "checksum mismatch",
+			artifact:      plugin.Artifact{File: "my-plugin/${name}", Checksum: "sha256:abc"},
+			expectedError: `checksum of ".+/my-plugin/my-plugin/my-plugin" mismatch`,
+		},
+		{
+			scenario:      "size mismatch",
+			artifact:      plugin.Artifact{File: "my-plugin/${name}", Size: 42},
+			expectedError: `size of ".+/my-plugin/my-plugin/my-plugin" mismatch, expected 42, got ` + strconv.FormatInt(fi.Size(), 10),
+		},
+		{
+			scenario: "success",
+			artifact: plugin.Artifact{File: "my-plugin/${name}", Checksum: checksum, Size: fi.Size()},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			registryDir := t.TempDir()
+			source := t.Name()
+
+			// Register installer.
+			installer.Register(source, func(_ context.Context, src string) bool {
+				return src == source
+			}, func(afero.Fs) installer.Installer {
+				return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
+					p := &plugin.Plugin{
+						Name:    "my-plugin",
+						Version: "v1.2.0",
+						Artifacts: plugin.Artifacts{
+							plugin.RuntimeArtifactIdentifier(): tc.artifact,
+						},
+					}
+
+					if err := aferocopy.Copy("resources/fixtures", filepath.Join(dest, p.Name)); err != nil {
+						return nil, err
+					}
+
+					return p, installerMock.InstallPlugin(afero.NewOsFs(), filepath.Join(dest, p.Name), p)
+				})
+			})
+
+			// Install plugin.
+			r, err := registry.NewRegistry(registryDir)
+			require.NoError(t, err)
+
+			err = r.Install(context.Background(), source)
+
+			exists, existsErr := afero.Exists(fs, filepath.Join(registryDir, "my-plugin"))
+			require.NoError(t, existsErr)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				assert.True(t, exists)
+			} else {
+				require.ErrorIs(t, err, plugin.ErrIntegrity)
+				assert.Regexp(t, tc.expectedError, err.Error())
+				assert.False(t, exists)
+			}
+		})
+	}
+}
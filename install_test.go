@@ -3,9 +3,12 @@ package registry_test
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	registry "github.com/nhatthm/plugin-registry"
@@ -19,41 +22,65 @@ import (
 func TestRegistry_Install(t *testing.T) {
 	t.Parallel()
 
-	registerInstaller := func(caseName string, construct func(t *testing.T) installer.Installer) func(t *testing.T) {
+	registerInstaller := func(caseName string, construct func(t *testing.T, fs afero.Fs) installer.Installer) func(t *testing.T) {
 		return func(t *testing.T) {
 			t.Helper()
 
 			installer.Register(caseName, func(_ context.Context, source string) bool {
 				return source == caseName
-			}, func(afero.Fs) installer.Installer {
-				return construct(t)
+			}, func(fs afero.Fs) installer.Installer {
+				return construct(t, fs)
 			})
 		}
 	}
 
-	registerFailInstaller := registerInstaller("INSTALL_FAIL", func(t *testing.T) installer.Installer {
+	registerFailInstaller := registerInstaller("INSTALL_FAIL", func(t *testing.T, _ afero.Fs) installer.Installer {
 		t.Helper()
 
 		return installerMock.Mock(func(i *installerMock.Installer) {
-			i.On("Install", context.Background(), "/tmp", "INSTALL_FAIL").
+			i.On("Install", mock.Anything, mock.Anything, "INSTALL_FAIL").
 				Return(nil, errors.New("install error"))
 		})(t)
 	})
 
-	registerSuccessInstaller := registerInstaller("INSTALL_SUCCESS", func(t *testing.T) installer.Installer {
+	registerInvalidInstaller := registerInstaller("INSTALL_INVALID", func(t *testing.T, _ afero.Fs) installer.Installer {
 		t.Helper()
 
 		return installerMock.Mock(func(i *installerMock.Installer) {
-			i.On("Install", context.Background(), "/tmp", "INSTALL_SUCCESS").
+			i.On("Install", mock.Anything, mock.Anything, "INSTALL_INVALID").
 				Return(&plugin.Plugin{Name: "my-plugin"}, nil)
 		})(t)
 	})
 
+	registerSuccessInstaller := registerInstaller("INSTALL_SUCCESS", func(t *testing.T, fs afero.Fs) installer.Installer {
+		t.Helper()
+
+		return installerMock.Mock(func(i *installerMock.Installer) {
+			i.On("Install", mock.Anything, mock.Anything, "INSTALL_SUCCESS").
+				Run(func(args mock.Arguments) {
+					err := installerMock.InstallPlugin(fs, filepath.Join(args.String(1), "my-plugin"), &plugin.Plugin{Name: "my-plugin"})
+					require.NoError(t, err)
+				}).
+				Return(&plugin.Plugin{Name: "my-plugin"}, nil)
+		})(t)
+	})
+
+	// The metadata is recorded as loaded, with the default artifact.
+	installedPlugin := plugin.Plugin{
+		Name:   "my-plugin",
+		Source: "INSTALL_SUCCESS",
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}-${version}-${os}-${arch}.tar.gz"},
+		},
+		Tags: plugin.Tags{},
+	}
+
 	testCases := []struct {
 		scenario          string
 		registerInstaller func(t *testing.T)
 		mockConfig        configuratorMock.Mocker
 		source            string
+		expectInstalled   bool
 		expectedError     string
 	}{
 		{
@@ -67,6 +94,12 @@ func TestRegistry_Install(t *testing.T) {
 			source:            "INSTALL_FAIL",
 			expectedError:     "install error",
 		},
+		{
+			scenario:          "invalid plugin",
+			registerInstaller: registerInvalidInstaller,
+			source:            "INSTALL_INVALID",
+			expectedError:     "could not read metadata",
+		},
 		{
 			scenario:          "could not get config",
 			registerInstaller: registerSuccessInstaller,
@@ -84,7 +117,7 @@ func TestRegistry_Install(t *testing.T) {
 				c.On("Config").
 					Return(config.Configuration{}, nil)
 
-				c.On("SetPlugin", plugin.Plugin{Name: "my-plugin"}).
+				c.On("SetPlugin", installedPlugin).
 					Return(errors.New("config error"))
 			}),
 			source:        "INSTALL_SUCCESS",
@@ -97,10 +130,11 @@ func TestRegistry_Install(t *testing.T) {
 				c.On("Config").
 					Return(config.Configuration{}, nil)
 
-				c.On("SetPlugin", plugin.Plugin{Name: "my-plugin"}).
+				c.On("SetPlugin", installedPlugin).
 					Return(nil)
 			}),
-			source: "INSTALL_SUCCESS",
+			source:          "INSTALL_SUCCESS",
+			expectInstalled: true,
 		},
 	}
 
@@ -117,16 +151,329 @@ func TestRegistry_Install(t *testing.T) {
 				tc.mockConfig = configuratorMock.NoMock
 			}
 
-			r, err := registry.NewRegistry("/tmp", registry.WithConfigurator(tc.mockConfig(t)))
+			fs := afero.NewMemMapFs()
+
+			r, err := registry.NewRegistry("/tmp", registry.WithFs(fs), registry.WithConfigurator(tc.mockConfig(t)))
 			require.NoError(t, err)
 
 			err = r.Install(context.Background(), tc.source)
 
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedError)
+			}
+
+			entries, err := afero.ReadDir(fs, "/tmp")
+			if tc.expectInstalled {
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				assert.Equal(t, "my-plugin", entries[0].Name())
+			} else if err == nil {
+				assert.Empty(t, entries)
+			}
+		})
+	}
+}
+
+func TestRegistry_InstallRequest(t *testing.T) {
+	t.Parallel()
+
+	installer.Register(t.Name(), func(_ context.Context, source string) bool {
+		return source == t.Name()
+	}, func(fs afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+			req := installer.RequestFromContext(ctx, src)
+
+			// The installer picks the version from the request.
+			version := "v1.4.2"
+			if req.Version.String() == ">=2" {
+				version = "v2.0.0"
+			}
+
+			p := &plugin.Plugin{Name: "my-plugin", Version: version}
+
+			return p, installerMock.InstallPlugin(fs, filepath.Join(dest, p.Name), p)
+		})
+	})
+
+	testCases := []struct {
+		scenario        string
+		request         installer.Request
+		expectedVersion string
+		expectedError   string
+	}{
+		{
+			scenario: "name mismatch",
+			request: installer.Request{
+				Source: t.Name(),
+				Name:   "another-plugin",
+			},
+			expectedError: "could not install plugin: plugin mismatch",
+		},
+		{
+			scenario: "version does not satisfy the constraint",
+			request: installer.Request{
+				Source:  t.Name(),
+				Version: plugin.MustParseConstraint("~1.5"),
+			},
+			expectedError: "could not install plugin: plugin version does not satisfy the constraint",
+		},
+		{
+			scenario: "no constraint",
+			request: installer.Request{
+				Source: t.Name(),
+			},
+			expectedVersion: "v1.4.2",
+		},
+		{
+			scenario: "version satisfies the constraint",
+			request: installer.Request{
+				Source:  t.Name(),
+				Name:    "my-plugin",
+				Version: plugin.MustParseConstraint("^1.4"),
+			},
+			expectedVersion: "v1.4.2",
+		},
+		{
+			scenario: "installer uses the constraint",
+			request: installer.Request{
+				Source:  t.Name(),
+				Version: plugin.MustParseConstraint(">=2"),
+			},
+			expectedVersion: "v2.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			c, err := config.NewMemConfigurator(config.NewFileConfigurator("/tmp/config.yaml").WithFs(fs))
+			require.NoError(t, err)
+
+			r, err := registry.NewRegistry("/tmp", registry.WithFs(fs), registry.WithConfigurator(c))
+			require.NoError(t, err)
+
+			err = r.InstallRequest(context.Background(), tc.request)
+
 			if tc.expectedError == "" {
 				require.NoError(t, err)
 			} else {
 				require.EqualError(t, err, tc.expectedError)
 			}
+
+			p, err := r.GetPlugin("my-plugin")
+			require.NoError(t, err)
+
+			if tc.expectedVersion == "" {
+				assert.Nil(t, p)
+			} else {
+				require.NotNil(t, p)
+				assert.Equal(t, tc.expectedVersion, p.Version)
+			}
+		})
+	}
+}
+
+func TestRegistry_Install_ExplicitInstaller(t *testing.T) {
+	t.Parallel()
+
+	var sources []string
+
+	installer.Register(t.Name(), func(context.Context, string) bool {
+		return false
+	}, func(fs afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+			sources = append(sources, src, installer.RequestFromContext(ctx, src).Source)
+
+			p := &plugin.Plugin{Name: "my-plugin"}
+
+			return p, installerMock.InstallPlugin(fs, filepath.Join(dest, p.Name), p)
+		})
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), t.Name()+":./my-plugin")
+	require.NoError(t, err)
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"./my-plugin", "./my-plugin"}, sources)
+	assert.Equal(t, t.Name()+":./my-plugin", p.Source)
+}
+
+func TestRegistry_Install_LoadedMetadata(t *testing.T) {
+	t.Parallel()
+
+	// The installer returns another version than the one in the metadata.
+	register := func(source string, metadata *plugin.Plugin) {
+		installer.Register(source, func(_ context.Context, src string) bool {
+			return src == source
+		}, func(fs afero.Fs) installer.Installer {
+			return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
+				p := &plugin.Plugin{Name: "my-plugin", URL: "https://example.org/my-plugin", Version: "v2.0.0", Revision: "abc"}
+
+				return p, installerMock.InstallPlugin(fs, filepath.Join(dest, p.Name), metadata)
+			})
+		})
+	}
+
+	register(t.Name()+"/v1", &plugin.Plugin{Name: "my-plugin", Version: "v1.0.0", Enabled: true})
+	register(t.Name()+"/other", &plugin.Plugin{Name: "other-plugin", Version: "v2.0.0"})
+	register(t.Name()+"/incompatible", &plugin.Plugin{
+		Name:          "my-plugin",
+		Version:       "v2.0.0",
+		Compatibility: plugin.Compatibility{Host: plugin.MustParseConstraint("^2")},
+	})
+
+	testCases := []struct {
+		scenario        string
+		request         installer.Request
+		expectedVersion string
+		expectedError   error
+	}{
+		{
+			scenario:        "version of the metadata",
+			request:         installer.Request{Source: t.Name() + "/v1"},
+			expectedVersion: "v1.0.0",
+		},
+		{
+			scenario:      "constraint is checked against the metadata",
+			request:       installer.Request{Source: t.Name() + "/v1", Version: plugin.MustParseConstraint("^2")},
+			expectedError: registry.ErrVersionNotSatisfied,
+		},
+		{
+			scenario:      "name of the metadata",
+			request:       installer.Request{Source: t.Name() + "/other"},
+			expectedError: registry.ErrPluginMismatch,
+		},
+		{
+			scenario:      "compatibility of the metadata",
+			request:       installer.Request{Source: t.Name() + "/incompatible"},
+			expectedError: plugin.ErrIncompatible,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := registry.NewRegistry("/tmp",
+				registry.WithFs(afero.NewMemMapFs()),
+				registry.WithHostVersion(plugin.MustParseVersion("1.0.0")),
+			)
+			require.NoError(t, err)
+
+			err = r.InstallRequest(context.Background(), tc.request)
+
+			p, getErr := r.GetPlugin("my-plugin")
+			require.NoError(t, getErr)
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, p)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, p)
+
+			assert.Equal(t, tc.expectedVersion, p.Version)
+			assert.True(t, p.Enabled)
+
+			// The url is the one of the metadata, the source is the requested one, only the revision is kept from the
+			// installer.
+			assert.Empty(t, p.URL)
+			assert.Equal(t, tc.request.Source, p.Source)
+			assert.Equal(t, "abc", p.Revision)
 		})
 	}
 }
+
+func TestRegistry_Install_InvalidName(t *testing.T) {
+	t.Parallel()
+
+	installer.Register(t.Name(), func(_ context.Context, src string) bool {
+		return src == t.Name()
+	}, func(afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+			return &plugin.Plugin{Name: "../my-plugin"}, nil
+		})
+	})
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/tmp/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), t.Name())
+	require.ErrorIs(t, err, plugin.ErrInvalidName)
+
+	entries, err := afero.ReadDir(fs, "/tmp")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "plugins", entries[0].Name())
+}
+
+func TestRegistry_Install_ArtifactOutsidePluginDir(t *testing.T) {
+	t.Parallel()
+
+	installerMock.RegisterPlugin(t.Name(), &plugin.Plugin{
+		Name:    "my-plugin",
+		Version: "v1.0.0",
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "../../secret", Checksum: "sha256:abc"},
+		},
+	})
+
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/secret", []byte("secret"), 0o644))
+
+	r, err := registry.NewRegistry("/tmp/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), t.Name())
+	require.ErrorIs(t, err, plugin.ErrInvalidArtifactPath)
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestRegistry_Install_RemoveStageDirs(t *testing.T) {
+	t.Parallel()
+
+	installerMock.RegisterPlugin(t.Name(), &plugin.Plugin{Name: "my-plugin", Version: "v1.0.0"})
+
+	fs := afero.NewMemMapFs()
+
+	// A crashed process left its stage directory.
+	require.NoError(t, afero.WriteFile(fs, "/tmp/.stage-123/my-plugin/my-plugin", []byte("hello"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/tmp/other-plugin/other-plugin", []byte("hello"), 0o644))
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), t.Name()))
+
+	entries, err := afero.ReadDir(fs, "/tmp")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	assert.Equal(t, []string{"config.yaml", "my-plugin", "other-plugin"}, names)
+}
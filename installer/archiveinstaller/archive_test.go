@@ -0,0 +1,114 @@
+package archiveinstaller_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	name   string
+	body   string
+	mode   os.FileMode
+	link   string
+	isDir  bool
+	isLink bool
+	// isGlobal is a pax global header, its body is the comment.
+	isGlobal bool
+}
+
+func file(name, body string, mode os.FileMode) entry {
+	return entry{name: name, body: body, mode: mode}
+}
+
+func dir(name string) entry {
+	return entry{name: name, mode: 0o755, isDir: true}
+}
+
+func symlink(name, target string) entry {
+	return entry{name: name, link: target, mode: 0o777, isLink: true}
+}
+
+func globalHeader(comment string) entry {
+	return entry{body: comment, isGlobal: true}
+}
+
+func makeTarGz(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: int64(e.mode), Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+
+		switch {
+		case e.isDir:
+			hdr.Typeflag = tar.TypeDir
+			hdr.Size = 0
+
+		case e.isLink:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.link
+			hdr.Size = 0
+
+		case e.isGlobal:
+			hdr = &tar.Header{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": e.body}}
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+
+		if hdr.Size > 0 {
+			_, err := tw.Write([]byte(e.body))
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries ...entry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+
+		switch {
+		case e.isDir:
+			hdr.SetMode(os.ModeDir | e.mode)
+
+		case e.isLink:
+			hdr.SetMode(os.ModeSymlink | e.mode)
+
+			body = e.link
+
+		default:
+			hdr.SetMode(e.mode)
+		}
+
+		w, err := zw.CreateHeader(hdr)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
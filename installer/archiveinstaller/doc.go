@@ -0,0 +1,5 @@
+// Package archiveinstaller provides an installer for the plugins packaged in a .tar.gz, .tgz or .zip archive. It is
+// registered as "archive" when the package is imported.
+//
+//	import _ "github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+package archiveinstaller
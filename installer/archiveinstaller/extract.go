@@ -0,0 +1,370 @@
+package archiveinstaller
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+)
+
+var (
+	// ErrUnsupportedFormat indicates that the archive format is not supported.
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+	// ErrUnsafePath indicates that an entry of the archive would be extracted outside of the destination.
+	ErrUnsafePath = errors.New("archive entry is outside of the destination")
+	// ErrSymlinkNotAllowed indicates that the archive has a symlink and the policy rejects it.
+	ErrSymlinkNotAllowed = errors.New("archive entry is a symlink")
+	// ErrUnsupportedEntry indicates that an entry of the archive is not a file, a directory or a symlink.
+	ErrUnsupportedEntry = errors.New("unsupported archive entry")
+)
+
+// Format is an archive format.
+type Format string
+
+const (
+	// FormatTarGz is a gzipped tarball.
+	FormatTarGz Format = "tar.gz"
+	// FormatZip is a zip archive.
+	FormatZip Format = "zip"
+)
+
+// SymlinkPolicy tells what to do with the symlinks in the archives.
+type SymlinkPolicy int
+
+const (
+	// SymlinkReject fails the extraction if the archive has a symlink. It is the default.
+	SymlinkReject SymlinkPolicy = iota
+	// SymlinkSkip ignores the symlinks.
+	SymlinkSkip
+	// SymlinkAllow creates the symlinks that point inside the destination, the file system must support symlinks.
+	SymlinkAllow
+)
+
+// Option configures the extraction.
+type Option func(o *options)
+
+type options struct {
+	symlinks SymlinkPolicy
+}
+
+// DetectFormat detects the format of an archive by its file extension.
+func DetectFormat(name string) (Format, bool) {
+	name = strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, true
+
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, true
+	}
+
+	return "", false
+}
+
+// Extract extracts the archive into the destination directory. The entries that would be extracted outside of the
+// destination are rejected, and the permissions of the files, including the executable bit, are preserved. A zip
+// archive is buffered into a temporary file of the file system because it can not be read as a stream.
+func Extract(fs afero.Fs, r io.Reader, format Format, dest string, opts ...Option) error {
+	o := options{symlinks: SymlinkReject}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	x := &extractor{fs: fs, dest: filepath.Clean(dest), options: o}
+
+	var err error
+
+	switch format {
+	case FormatTarGz:
+		err = x.extractTarGz(r)
+
+	case FormatZip:
+		err = x.extractZip(r)
+
+	default:
+		err = ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return ctxd.WrapError(context.Background(), err, "could not extract archive", "format", string(format))
+	}
+
+	return nil
+}
+
+// WithSymlinkPolicy sets what to do with the symlinks in the archives.
+func WithSymlinkPolicy(p SymlinkPolicy) Option {
+	return func(o *options) {
+		o.symlinks = p
+	}
+}
+
+type extractor struct {
+	fs      afero.Fs
+	dest    string
+	options options
+}
+
+func (x *extractor) extractTarGz(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+
+	defer gz.Close() //nolint: errcheck
+
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.mkdir(hdr.Name)
+
+		case tar.TypeReg, tar.TypeRegA: //nolint: staticcheck
+			err = x.writeFile(hdr.Name, os.FileMode(hdr.Mode).Perm(), tr) //nolint: gosec
+
+		case tar.TypeSymlink:
+			err = x.symlink(hdr.Name, hdr.Linkname)
+
+		case tar.TypeXGlobalHeader:
+			// The metadata of the archive, such as the commit written by "git archive".
+
+		default:
+			err = ctxd.WrapError(context.Background(), ErrUnsupportedEntry, "could not extract entry", "name", hdr.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) extractZip(r io.Reader) error {
+	ra, size, cleanup, err := x.readerAt(r)
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if err := x.extractZipFile(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) extractZipFile(f *zip.File) error {
+	mode := f.Mode()
+
+	switch {
+	case mode.IsDir():
+		return x.mkdir(f.Name)
+
+	case mode&os.ModeSymlink != 0:
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		defer rc.Close() //nolint: errcheck
+
+		target, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+
+		return x.symlink(f.Name, string(target))
+
+	case mode.IsRegular():
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		defer rc.Close() //nolint: errcheck
+
+		return x.writeFile(f.Name, mode.Perm(), rc)
+	}
+
+	return ctxd.WrapError(context.Background(), ErrUnsupportedEntry, "could not extract entry", "name", f.Name)
+}
+
+// readerAt returns the reader if it can be read at random positions, or buffers it into a temporary file.
+func (x *extractor) readerAt(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	if f, ok := r.(afero.File); ok {
+		if fi, err := f.Stat(); err == nil {
+			return f, fi.Size(), func() {}, nil
+		}
+	}
+
+	tmp, err := afero.TempFile(x.fs, "", "plugin-registry-*.zip")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	cleanup := func() {
+		_ = tmp.Close()             //nolint: errcheck
+		_ = x.fs.Remove(tmp.Name()) //nolint: errcheck
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+
+		return nil, 0, nil, err
+	}
+
+	return tmp, size, cleanup, nil
+}
+
+// path returns the path of an entry in the destination, it must not be outside of the destination.
+func (x *extractor) path(name string) (string, error) {
+	name = filepath.FromSlash(name)
+
+	if filepath.IsAbs(name) || strings.HasPrefix(name, `\`) {
+		return "", ctxd.WrapError(context.Background(), ErrUnsafePath, "could not extract entry", "name", name)
+	}
+
+	path := filepath.Join(x.dest, name)
+
+	if !isInside(x.dest, path) || x.hasSymlinkParent(path) {
+		return "", ctxd.WrapError(context.Background(), ErrUnsafePath, "could not extract entry", "name", name)
+	}
+
+	return path, nil
+}
+
+// hasSymlinkParent checks whether an entry would be extracted through a symlink of the archive, which could point
+// outside of the destination.
+func (x *extractor) hasSymlinkParent(path string) bool {
+	lstater, ok := x.fs.(afero.Lstater)
+	if !ok || x.options.symlinks != SymlinkAllow {
+		return false
+	}
+
+	rel, err := filepath.Rel(x.dest, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return false
+	}
+
+	parent := x.dest
+
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		parent = filepath.Join(parent, part)
+
+		fi, _, err := lstater.LstatIfPossible(parent)
+		if err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (x *extractor) mkdir(name string) error {
+	path, err := x.path(name)
+	if err != nil {
+		return err
+	}
+
+	return x.fs.MkdirAll(path, 0o755)
+}
+
+func (x *extractor) writeFile(name string, perm os.FileMode, r io.Reader) error {
+	path, err := x.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := x.fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := x.fs.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil { //nolint: gosec
+		_ = f.Close() //nolint: errcheck
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// The permissions of a new file are masked by the umask.
+	return x.fs.Chmod(path, perm)
+}
+
+func (x *extractor) symlink(name, target string) error {
+	switch x.options.symlinks {
+	case SymlinkSkip:
+		return nil
+
+	case SymlinkAllow:
+
+	default:
+		return ctxd.WrapError(context.Background(), ErrSymlinkNotAllowed, "could not extract entry", "name", name)
+	}
+
+	path, err := x.path(name)
+	if err != nil {
+		return err
+	}
+
+	target = filepath.FromSlash(target)
+
+	if filepath.IsAbs(target) || !isInside(x.dest, filepath.Join(filepath.Dir(path), target)) {
+		return ctxd.WrapError(context.Background(), ErrUnsafePath, "could not extract entry",
+			"name", name,
+			"target", target,
+		)
+	}
+
+	linker, ok := x.fs.(afero.Linker)
+	if !ok {
+		return ctxd.WrapError(context.Background(), ErrSymlinkNotAllowed, "could not extract entry", "name", name)
+	}
+
+	if err := x.fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return linker.SymlinkIfPossible(target, path)
+}
+
+func isInside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,261 @@
+package archiveinstaller_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+)
+
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		expected archiveinstaller.Format
+	}{
+		{name: "my-plugin-1.0.0-linux-amd64.tar.gz", expected: archiveinstaller.FormatTarGz},
+		{name: "my-plugin.TGZ", expected: archiveinstaller.FormatTarGz},
+		{name: "my-plugin.zip", expected: archiveinstaller.FormatZip},
+		{name: "my-plugin.tar"},
+		{name: "my-plugin"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, ok := archiveinstaller.DetectFormat(tc.name)
+
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expected != "", ok)
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	entries := []entry{
+		dir("bin/"),
+		file("bin/my-plugin", "#!/bin/bash\n", 0o755),
+		file("README.md", "hello", 0o644),
+		file("docs/guide.md", "guide", 0o600),
+	}
+
+	testCases := []struct {
+		scenario string
+		format   archiveinstaller.Format
+		data     []byte
+	}{
+		{
+			scenario: "tar.gz",
+			format:   archiveinstaller.FormatTarGz,
+			data:     makeTarGz(t, entries...),
+		},
+		{
+			scenario: "zip",
+			format:   archiveinstaller.FormatZip,
+			data:     makeZip(t, entries...),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			dest := t.TempDir()
+			fs := afero.NewOsFs()
+
+			err := archiveinstaller.Extract(fs, bytes.NewReader(tc.data), tc.format, dest)
+			require.NoError(t, err)
+
+			expected := map[string]os.FileMode{
+				"bin/my-plugin": 0o755,
+				"README.md":     0o644,
+				"docs/guide.md": 0o600,
+			}
+
+			for name, mode := range expected {
+				fi, err := os.Stat(filepath.Join(dest, name))
+				require.NoError(t, err)
+
+				assert.Equal(t, mode, fi.Mode().Perm(), name)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dest, "README.md")) //nolint: gosec
+			require.NoError(t, err)
+
+			assert.Equal(t, "hello", string(data))
+		})
+	}
+}
+
+func TestExtract_UnsafePath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		entries  []entry
+		options  []archiveinstaller.Option
+	}{
+		{
+			scenario: "parent directory",
+			entries:  []entry{file("../evil", "evil", 0o644)},
+		},
+		{
+			scenario: "nested parent directory",
+			entries:  []entry{file("bin/../../evil", "evil", 0o644)},
+		},
+		{
+			scenario: "absolute path",
+			entries:  []entry{file("/tmp/evil", "evil", 0o644)},
+		},
+		{
+			scenario: "symlink outside",
+			entries:  []entry{symlink("evil", "../outside")},
+			options:  []archiveinstaller.Option{archiveinstaller.WithSymlinkPolicy(archiveinstaller.SymlinkAllow)},
+		},
+		{
+			scenario: "absolute symlink",
+			entries:  []entry{symlink("evil", "/etc/passwd")},
+			options:  []archiveinstaller.Option{archiveinstaller.WithSymlinkPolicy(archiveinstaller.SymlinkAllow)},
+		},
+		{
+			scenario: "write through symlink",
+			entries: []entry{
+				dir("a/"),
+				symlink("a/b", "."),
+				file("a/b/evil", "evil", 0o644),
+			},
+			options: []archiveinstaller.Option{archiveinstaller.WithSymlinkPolicy(archiveinstaller.SymlinkAllow)},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		for _, format := range []archiveinstaller.Format{archiveinstaller.FormatTarGz, archiveinstaller.FormatZip} {
+			format := format
+
+			t.Run(tc.scenario+" "+string(format), func(t *testing.T) {
+				t.Parallel()
+
+				data := makeTarGz(t, tc.entries...)
+				if format == archiveinstaller.FormatZip {
+					data = makeZip(t, tc.entries...)
+				}
+
+				dest := filepath.Join(t.TempDir(), "dest")
+
+				err := archiveinstaller.Extract(afero.NewOsFs(), bytes.NewReader(data), format, dest, tc.options...)
+
+				require.ErrorIs(t, err, archiveinstaller.ErrUnsafePath)
+			})
+		}
+	}
+}
+
+func TestExtract_Symlink(t *testing.T) {
+	t.Parallel()
+
+	entries := []entry{
+		file("bin/my-plugin", "#!/bin/bash\n", 0o755),
+		symlink("my-plugin", "bin/my-plugin"),
+	}
+
+	testCases := []struct {
+		scenario      string
+		policy        archiveinstaller.SymlinkPolicy
+		expectedLink  bool
+		expectedError error
+	}{
+		{
+			scenario:      "reject",
+			policy:        archiveinstaller.SymlinkReject,
+			expectedError: archiveinstaller.ErrSymlinkNotAllowed,
+		},
+		{
+			scenario: "skip",
+			policy:   archiveinstaller.SymlinkSkip,
+		},
+		{
+			scenario:     "allow",
+			policy:       archiveinstaller.SymlinkAllow,
+			expectedLink: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			dest := t.TempDir()
+
+			err := archiveinstaller.Extract(afero.NewOsFs(), bytes.NewReader(makeTarGz(t, entries...)),
+				archiveinstaller.FormatTarGz, dest, archiveinstaller.WithSymlinkPolicy(tc.policy),
+			)
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			target, err := os.Readlink(filepath.Join(dest, "my-plugin"))
+
+			if tc.expectedLink {
+				require.NoError(t, err)
+				assert.Equal(t, "bin/my-plugin", target)
+			} else {
+				require.ErrorIs(t, err, os.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestExtract_GlobalHeader(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	data := makeTarGz(t, globalHeader("0123456789abcdef"), file("my-plugin", "hello", 0o755))
+
+	err := archiveinstaller.Extract(fs, bytes.NewReader(data), archiveinstaller.FormatTarGz, "/dest")
+	require.NoError(t, err)
+
+	actual, err := afero.ReadFile(fs, "/dest/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", string(actual))
+}
+
+func TestExtract_SymlinkNotSupported(t *testing.T) {
+	t.Parallel()
+
+	data := makeZip(t, symlink("my-plugin", "bin/my-plugin"))
+
+	err := archiveinstaller.Extract(afero.NewMemMapFs(), bytes.NewReader(data), archiveinstaller.FormatZip, "/dest",
+		archiveinstaller.WithSymlinkPolicy(archiveinstaller.SymlinkAllow),
+	)
+
+	require.ErrorIs(t, err, archiveinstaller.ErrSymlinkNotAllowed)
+}
+
+func TestExtract_UnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	err := archiveinstaller.Extract(afero.NewMemMapFs(), bytes.NewReader(nil), "rar", "/dest")
+
+	require.ErrorIs(t, err, archiveinstaller.ErrUnsupportedFormat)
+}
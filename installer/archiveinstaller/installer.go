@@ -0,0 +1,149 @@
+package archiveinstaller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const (
+	// Name is the name of the installer, use it as a prefix to force the installer, such as "archive:./my-plugin.zip".
+	Name = "archive"
+	// Priority is the priority of the installer, it is tried before the installers of the local files.
+	Priority = installer.DefaultPriority + 10
+
+	filePrefix = "file://"
+)
+
+// ErrMetadataNotFound indicates that the metadata file is neither at the root of the archive nor in its single top-level
+// folder.
+var ErrMetadataNotFound = errors.New("metadata file not found in archive")
+
+var _ installer.Installer = (*Installer)(nil)
+
+func init() { //nolint: gochecknoinits
+	installer.RegisterWithPriority(Name, Priority, IsValid, func(fs afero.Fs) installer.Installer {
+		return New(fs)
+	})
+}
+
+// Installer installs the plugins from a local archive.
+type Installer struct {
+	fs      afero.Fs
+	options []Option
+}
+
+// Install extracts the archive into "<dest>/<name>", see Unpack().
+func (i *Installer) Install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	path := strings.TrimPrefix(src, filePrefix)
+
+	format, ok := DetectFormat(path)
+	if !ok {
+		return nil, ctxd.WrapError(ctx, ErrUnsupportedFormat, "could not install plugin", "source", src)
+	}
+
+	f, err := i.fs.Open(path)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	p, err := Unpack(i.fs, dest, format, f, i.options...)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	return p, nil
+}
+
+// New creates a new installer.
+func New(fs afero.Fs, opts ...Option) *Installer {
+	return &Installer{fs: fs, options: opts}
+}
+
+// IsValid checks whether the source is a local file with the extension of a supported archive. The source may be
+// prefixed by "file://".
+func IsValid(ctx context.Context, src string) bool {
+	path := strings.TrimPrefix(src, filePrefix)
+
+	if _, ok := DetectFormat(path); !ok {
+		return false
+	}
+
+	fi, err := fsCtx.Fs(ctx).Stat(path)
+
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// Unpack extracts the archive into "<dest>/<name>". The metadata file must be at the root of the archive or in its
+// single top-level folder, such as "my-plugin-1.0.0/.plugin.registry.yaml".
+func Unpack(fs afero.Fs, dest string, format Format, r io.Reader, opts ...Option) (*plugin.Plugin, error) {
+	if err := fs.MkdirAll(dest, 0o755); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := afero.TempDir(fs, dest, ".extract-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer fs.RemoveAll(tmpDir) //nolint: errcheck
+
+	if err := Extract(fs, r, format, tmpDir, opts...); err != nil {
+		return nil, err
+	}
+
+	root, err := findRoot(fs, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := plugin.Load(fs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plugin.IsValidName(p.Name) {
+		return nil, ctxd.WrapError(context.Background(), plugin.ErrInvalidName, "could not unpack plugin", "name", p.Name)
+	}
+
+	pluginDir := filepath.Join(dest, p.Name)
+
+	if err := fs.Rename(root, pluginDir); err != nil {
+		return nil, err
+	}
+
+	return plugin.Load(fs, pluginDir)
+}
+
+// findRoot finds the directory of the metadata file, the root of the archive or its single top-level folder.
+func findRoot(fs afero.Fs, dir string) (string, error) {
+	if ok, err := afero.Exists(fs, filepath.Join(dir, plugin.MetadataFile)); err != nil || ok {
+		return dir, err
+	}
+
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 1 && entries[0].IsDir() {
+		root := filepath.Join(dir, entries[0].Name())
+
+		if ok, err := afero.Exists(fs, filepath.Join(root, plugin.MetadataFile)); err != nil || ok {
+			return root, err
+		}
+	}
+
+	return "", ErrMetadataNotFound
+}
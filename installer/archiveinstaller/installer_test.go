@@ -0,0 +1,155 @@
+package archiveinstaller_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+	_ "github.com/nhatthm/plugin-registry/installer/fsinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const metadata = `name: my-plugin
+version: v1.0.0
+artifacts:
+    linux:
+        file: bin/my-plugin
+`
+
+func TestUnpack(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		entries       []entry
+		expectedError error
+	}{
+		{
+			scenario: "metadata at root",
+			entries: []entry{
+				file(plugin.MetadataFile, metadata, 0o644),
+				file("bin/my-plugin", "#!/bin/bash\n", 0o755),
+			},
+		},
+		{
+			scenario: "metadata in top-level folder",
+			entries: []entry{
+				dir("my-plugin-1.0.0/"),
+				file("my-plugin-1.0.0/"+plugin.MetadataFile, metadata, 0o644),
+				file("my-plugin-1.0.0/bin/my-plugin", "#!/bin/bash\n", 0o755),
+			},
+		},
+		{
+			scenario: "metadata in nested folder",
+			entries: []entry{
+				file("my-plugin-1.0.0/nested/"+plugin.MetadataFile, metadata, 0o644),
+			},
+			expectedError: archiveinstaller.ErrMetadataNotFound,
+		},
+		{
+			scenario: "invalid name",
+			entries: []entry{
+				file(plugin.MetadataFile, "name: ../my-plugin", 0o644),
+			},
+			expectedError: plugin.ErrInvalidName,
+		},
+		{
+			scenario: "several top-level folders",
+			entries: []entry{
+				file("my-plugin-1.0.0/"+plugin.MetadataFile, metadata, 0o644),
+				file("other/README.md", "", 0o644),
+			},
+			expectedError: archiveinstaller.ErrMetadataNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			p, err := archiveinstaller.Unpack(fs, "/dest", archiveinstaller.FormatTarGz, bytes.NewReader(makeTarGz(t, tc.entries...)))
+
+			entries, readErr := afero.ReadDir(fs, "/dest")
+			require.NoError(t, readErr)
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, p)
+				assert.Empty(t, entries)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, "my-plugin", p.Name)
+			assert.Equal(t, "v1.0.0", p.Version)
+			require.Len(t, entries, 1)
+			assert.Equal(t, "my-plugin", entries[0].Name())
+
+			fi, err := fs.Stat("/dest/my-plugin/bin/my-plugin")
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/src/my-plugin.zip", nil, 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/src/my-plugin", nil, 0o755))
+
+	ctx := fsCtx.WithFs(context.Background(), fs)
+
+	assert.True(t, archiveinstaller.IsValid(ctx, "/src/my-plugin.zip"))
+	assert.True(t, archiveinstaller.IsValid(ctx, "file:///src/my-plugin.zip"))
+	assert.False(t, archiveinstaller.IsValid(ctx, "/src/my-plugin"))
+	assert.False(t, archiveinstaller.IsValid(ctx, "/src/unknown.tar.gz"))
+}
+
+func TestRegistry_Install(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	data := makeZip(t,
+		file("my-plugin/"+plugin.MetadataFile, metadata, 0o644),
+		file("my-plugin/bin/my-plugin", "#!/bin/bash\n", 0o755),
+	)
+
+	require.NoError(t, afero.WriteFile(fs, "/src/my-plugin-1.0.0.zip", data, 0o644))
+
+	ctx := fsCtx.WithFs(context.Background(), fs)
+
+	// The local file installer supports the archive too, but it has a lower priority.
+	assert.Equal(t, []string{"archive", "fs"}, installer.Matches(ctx, "/src/my-plugin-1.0.0.zip"))
+
+	r, err := registry.NewRegistry("/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), "/src/my-plugin-1.0.0.zip"))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+
+	ok, err := afero.Exists(fs, "/plugins/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
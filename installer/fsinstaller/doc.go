@@ -0,0 +1,5 @@
+// Package fsinstaller provides an installer for the plugins in a local directory or a single binary. It is registered
+// as "fs" when the package is imported.
+//
+//	import _ "github.com/nhatthm/plugin-registry/installer/fsinstaller"
+package fsinstaller
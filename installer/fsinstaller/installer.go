@@ -0,0 +1,147 @@
+package fsinstaller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+	"go.nhat.io/aferocopy/v2"
+	"gopkg.in/yaml.v3"
+
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// Name is the name of the installer, use it as a prefix to force the installer, such as "fs:./my-plugin".
+const Name = "fs"
+
+const filePrefix = "file://"
+
+var _ installer.Installer = (*Installer)(nil)
+
+func init() { //nolint: gochecknoinits
+	installer.Register(Name, IsValid, func(fs afero.Fs) installer.Installer {
+		return New(fs)
+	})
+}
+
+// Installer installs the plugins from a local directory that has the metadata file, or from a single binary.
+type Installer struct {
+	fs afero.Fs
+}
+
+// Install copies the plugin into "<dest>/<name>". The metadata of a single binary is generated, its name is the name
+// of the request, or the name of the file without extension.
+func (i *Installer) Install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	path := trimSource(src)
+
+	fi, err := i.fs.Stat(path)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	var p *plugin.Plugin
+
+	if fi.IsDir() {
+		p, err = i.installDir(dest, path)
+	} else {
+		p, err = i.installFile(dest, path, installer.RequestFromContext(ctx, src).Name)
+	}
+
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	return p, nil
+}
+
+func (i *Installer) installDir(dest, path string) (*plugin.Plugin, error) {
+	p, err := plugin.Load(i.fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plugin.IsValidName(p.Name) {
+		return nil, fmt.Errorf("%w: %q", plugin.ErrInvalidName, p.Name)
+	}
+
+	pluginDir := filepath.Join(dest, p.Name)
+
+	if err := aferocopy.Copy(path, pluginDir, aferocopy.Options{SrcFs: i.fs, DestFs: i.fs}); err != nil {
+		return nil, err
+	}
+
+	return plugin.Load(i.fs, pluginDir)
+}
+
+func (i *Installer) installFile(dest, path, name string) (*plugin.Plugin, error) {
+	file := filepath.Base(path)
+
+	if name == "" {
+		name = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+
+	if !plugin.IsValidName(name) {
+		return nil, fmt.Errorf("%w: %q", plugin.ErrInvalidName, name)
+	}
+
+	pluginDir := filepath.Join(dest, name)
+
+	if err := aferocopy.Copy(path, filepath.Join(pluginDir, file), aferocopy.Options{SrcFs: i.fs, DestFs: i.fs}); err != nil {
+		return nil, err
+	}
+
+	metadata, err := yaml.Marshal(plugin.Plugin{
+		Name:    name,
+		Enabled: true,
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: file},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := afero.WriteFile(i.fs, filepath.Join(pluginDir, plugin.MetadataFile), metadata, 0o644); err != nil {
+		return nil, err
+	}
+
+	return plugin.Load(i.fs, pluginDir)
+}
+
+// New creates a new installer.
+func New(fs afero.Fs) *Installer {
+	return &Installer{fs: fs}
+}
+
+// IsValid checks whether the source is a local directory that has the metadata file, or a file. The source may be
+// prefixed by "file://".
+func IsValid(ctx context.Context, src string) bool {
+	fs := fsCtx.Fs(ctx)
+	path := trimSource(src)
+
+	if path == "" {
+		return false
+	}
+
+	fi, err := fs.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	if !fi.IsDir() {
+		return fi.Mode().IsRegular()
+	}
+
+	ok, err := afero.Exists(fs, filepath.Join(path, plugin.MetadataFile))
+
+	return err == nil && ok
+}
+
+func trimSource(src string) string {
+	return strings.TrimPrefix(src, filePrefix)
+}
@@ -0,0 +1,227 @@
+package fsinstaller_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/fsinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func newFs(t *testing.T) afero.Fs {
+	t.Helper()
+
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/src/my-plugin/"+plugin.MetadataFile, []byte(`name: my-plugin
+version: v1.0.0
+artifacts:
+    linux:
+        file: my-plugin
+`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/src/my-plugin/my-plugin", []byte("#!/bin/bash\n"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/src/bin/my-tool.sh", []byte("#!/bin/bash\n"), 0o755))
+	require.NoError(t, fs.MkdirAll("/src/empty", 0o755))
+
+	return fs
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected bool
+	}{
+		{source: "/src/my-plugin", expected: true},
+		{source: "file:///src/my-plugin", expected: true},
+		{source: "/src/bin/my-tool.sh", expected: true},
+		{source: "/src/empty"},
+		{source: "/src/unknown"},
+		{source: "file://"},
+		{source: "github.com/example/my-plugin"},
+	}
+
+	ctx := fsCtx.WithFs(context.Background(), newFs(t))
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.source, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, fsinstaller.IsValid(ctx, tc.source))
+		})
+	}
+}
+
+func TestInstaller_Install_Dir(t *testing.T) {
+	t.Parallel()
+
+	fs := newFs(t)
+
+	p, err := fsinstaller.New(fs).Install(context.Background(), "/dest", "file:///src/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-plugin", p.Name)
+	assert.Equal(t, "v1.0.0", p.Version)
+
+	for _, file := range []string{plugin.MetadataFile, "my-plugin"} {
+		ok, err := afero.Exists(fs, filepath.Join("/dest/my-plugin", file))
+		require.NoError(t, err)
+		assert.True(t, ok, file)
+	}
+}
+
+func TestInstaller_Install_File(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario     string
+		name         string
+		expectedName string
+	}{
+		{
+			scenario:     "name from file",
+			expectedName: "my-tool",
+		},
+		{
+			scenario:     "name from request",
+			name:         "my-plugin",
+			expectedName: "my-plugin",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFs(t)
+			ctx := installer.WithRequest(context.Background(), installer.Request{
+				Source: "/src/bin/my-tool.sh",
+				Name:   tc.name,
+			})
+
+			p, err := fsinstaller.New(fs).Install(ctx, "/dest", "/src/bin/my-tool.sh")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedName, p.Name)
+			assert.Equal(t, "my-tool.sh", p.ResolveArtifact(p.RuntimeArtifact()).File)
+
+			fi, err := fs.Stat(filepath.Join("/dest", tc.expectedName, "my-tool.sh"))
+			require.NoError(t, err)
+
+			assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+		})
+	}
+}
+
+func TestInstaller_Install_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		source        string
+		expectedError error
+	}{
+		{
+			scenario:      "not found",
+			source:        "/src/unknown",
+			expectedError: os.ErrNotExist,
+		},
+		{
+			scenario:      "no metadata",
+			source:        "/src/empty",
+			expectedError: os.ErrNotExist,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := fsinstaller.New(newFs(t)).Install(context.Background(), "/dest", tc.source)
+
+			assert.Nil(t, p)
+			require.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestInstaller_Install_InvalidName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		source   string
+		name     string
+	}{
+		{
+			scenario: "name from metadata",
+			source:   "/src/escaped",
+		},
+		{
+			scenario: "name from request",
+			source:   "/src/bin/my-tool.sh",
+			name:     "../../escaped",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFs(t)
+
+			require.NoError(t, afero.WriteFile(fs, "/src/escaped/"+plugin.MetadataFile, []byte("name: ../../escaped\n"), 0o644))
+			require.NoError(t, afero.WriteFile(fs, "/src/escaped/payload", []byte("payload"), 0o644))
+
+			ctx := installer.WithRequest(context.Background(), installer.Request{Source: tc.source, Name: tc.name})
+
+			p, err := fsinstaller.New(fs).Install(ctx, "/reg/plugins/.stage-1", tc.source)
+
+			assert.Nil(t, p)
+			require.ErrorIs(t, err, plugin.ErrInvalidName)
+
+			ok, err := afero.Exists(fs, "/reg")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestRegistry_Install(t *testing.T) {
+	t.Parallel()
+
+	fs := newFs(t)
+
+	r, err := registry.NewRegistry("/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), "fs:/src/bin/my-tool.sh"))
+	require.NoError(t, r.Install(context.Background(), "/src/my-plugin"))
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"my-plugin", "my-tool"}, []string{
+		cfg.Plugins.Query()[0].Name,
+		cfg.Plugins.Query()[1].Name,
+	})
+	assert.Equal(t, "fs:/src/bin/my-tool.sh", cfg.Plugins["my-tool"].Source)
+
+	ok, err := afero.Exists(fs, "/plugins/my-tool/my-tool.sh")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
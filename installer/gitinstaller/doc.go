@@ -0,0 +1,6 @@
+// Package gitinstaller provides an installer for the plugins in git repositories, such as
+// "git+https://example.org/my-plugin.git#v1.0.0". It is registered as "git" when the package is imported, use
+// Register() to configure it.
+//
+//	import _ "github.com/nhatthm/plugin-registry/installer/gitinstaller"
+package gitinstaller
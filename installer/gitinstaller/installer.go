@@ -0,0 +1,207 @@
+package gitinstaller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// Name is the name of the installer.
+const Name = "git"
+
+const sourcePrefix = "git+"
+
+var supportedSchemes = []string{"file://", "http://", "https://"}
+
+var (
+	// ErrInvalidSource indicates that the source is not a git repository with a supported scheme.
+	ErrInvalidSource = errors.New("invalid git source")
+	// ErrInvalidRef indicates that the ref is malformed.
+	ErrInvalidRef = errors.New("invalid git ref")
+	// ErrCommandFailed indicates that a git command failed.
+	ErrCommandFailed = errors.New("git command failed")
+)
+
+var _ installer.Installer = (*Installer)(nil)
+
+func init() { //nolint: gochecknoinits
+	Register()
+}
+
+// Option configures Installer.
+type Option func(i *Installer)
+
+// Installer installs the plugins from a git repository with the git command. The tree of the ref, or of the default
+// branch if there is no ref, is exported into the plugin directory. The metadata file must be at the root of the
+// repository.
+type Installer struct {
+	fs afero.Fs
+
+	command        string
+	extractOptions []archiveinstaller.Option
+}
+
+// Install exports the repository into "<dest>/<name>". The revision of the plugin is the resolved commit.
+func (i *Installer) Install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	p, err := i.install(ctx, dest, src)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	return p, nil
+}
+
+func (i *Installer) install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	repo, ref, err := ParseSource(src)
+	if err != nil {
+		return nil, err
+	}
+
+	cloneDir, err := os.MkdirTemp("", "plugin-registry-git-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(cloneDir) //nolint: errcheck
+
+	// A bare clone has the branches of the repository as its own, so they are resolved like the tags and the commits.
+	if _, err := i.git(ctx, nil, "clone", "--bare", "--quiet", "--", repo, cloneDir); err != nil {
+		return nil, err
+	}
+
+	out, err := i.git(ctx, nil, "-C", cloneDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not resolve ref", "ref", ref)
+	}
+
+	commit := strings.TrimSpace(string(out))
+
+	var archive bytes.Buffer
+
+	// Git only tracks the executable bit, the files are exported as 0644 or 0755 regardless of the umask.
+	if _, err := i.git(ctx, &archive, "-C", cloneDir, "-c", "tar.umask=022", "archive", "--format=tar.gz", commit); err != nil {
+		return nil, err
+	}
+
+	p, err := archiveinstaller.Unpack(i.fs, dest, archiveinstaller.FormatTarGz, &archive, i.extractOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Revision = commit
+
+	return p, nil
+}
+
+// git runs a git command, and writes its output to w, or returns it if w is nil.
+func (i *Installer) git(ctx context.Context, w io.Writer, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, i.command, args...) //nolint: gosec
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if w != nil {
+		cmd.Stdout = w
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrCommandFailed, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// New creates a new installer.
+func New(fs afero.Fs, options ...Option) *Installer {
+	i := &Installer{
+		fs:      fs,
+		command: "git",
+	}
+
+	for _, o := range options {
+		o(i)
+	}
+
+	return i
+}
+
+// Register registers the installer with the options, it replaces the installer registered with the default options
+// when the package is imported.
+//
+//	gitinstaller.Register(gitinstaller.WithCommand("/usr/local/bin/git"))
+func Register(options ...Option) {
+	installer.Register(Name, IsValid, func(fs afero.Fs) installer.Installer {
+		return New(fs, options...)
+	})
+}
+
+// IsValid checks whether the source is a git repository, such as "git+file:///path/to/repo.git" or
+// "git+https://example.org/my-plugin.git#v1.0.0".
+func IsValid(_ context.Context, src string) bool {
+	_, _, err := ParseSource(src)
+
+	return err == nil
+}
+
+// ParseSource parses a source, such as "git+https://example.org/my-plugin.git#v1.0.0", into the url of the repository
+// and the ref, that is a tag, a branch or a commit. The ref is "HEAD" if there is none.
+func ParseSource(src string) (string, string, error) {
+	if !strings.HasPrefix(src, sourcePrefix) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidSource, src)
+	}
+
+	repo, ref := strings.TrimPrefix(src, sourcePrefix), "HEAD"
+
+	if pos := strings.LastIndex(repo, "#"); pos >= 0 {
+		repo, ref = repo[:pos], repo[pos+1:]
+	}
+
+	if !hasSupportedScheme(repo) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidSource, src)
+	}
+
+	// The ref must not be taken as an option of the git command.
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	return repo, ref, nil
+}
+
+func hasSupportedScheme(repo string) bool {
+	for _, scheme := range supportedSchemes {
+		if strings.HasPrefix(repo, scheme) && len(repo) > len(scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithCommand sets the path of the git command. The default is "git" in the PATH.
+func WithCommand(command string) Option {
+	return func(i *Installer) {
+		i.command = command
+	}
+}
+
+// WithExtractOptions sets the options to extract the tree of the repository, for example to allow the symlinks.
+func WithExtractOptions(options ...archiveinstaller.Option) Option {
+	return func(i *Installer) {
+		i.extractOptions = append(i.extractOptions, options...)
+	}
+}
@@ -0,0 +1,310 @@
+package gitinstaller_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/installer/gitinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// repository is a bare repository, that is pushed from a work tree.
+type repository struct {
+	t       *testing.T
+	bare    string
+	workDir string
+}
+
+func newRepository(t *testing.T) *repository {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	r := &repository{t: t, bare: filepath.Join(dir, "my-plugin.git"), workDir: filepath.Join(dir, "work")}
+
+	r.git(dir, "init", "--quiet", "--bare", "--initial-branch=main", r.bare)
+	r.git(dir, "init", "--quiet", "--initial-branch=main", r.workDir)
+
+	return r
+}
+
+func (r *repository) git(dir string, args ...string) string {
+	r.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.org",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.org",
+	)
+
+	out, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, string(out))
+
+	return strings.TrimSpace(string(out))
+}
+
+// commit commits the plugin of the version into the branch, and returns the commit.
+func (r *repository) commit(branch, version, script string) string {
+	r.t.Helper()
+
+	metadata := fmt.Sprintf("name: my-plugin\nversion: %s\nartifacts:\n    %s/%s:\n        file: my-plugin.sh\n",
+		version, runtime.GOOS, runtime.GOARCH)
+
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.workDir, plugin.MetadataFile), []byte(metadata), 0o644))
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.workDir, "my-plugin.sh"), []byte(script), 0o755)) //nolint: gosec
+
+	r.git(r.workDir, "checkout", "--quiet", "-B", branch)
+	r.git(r.workDir, "add", "--all")
+	r.git(r.workDir, "commit", "--quiet", "--allow-empty", "-m", version)
+	r.git(r.workDir, "push", "--quiet", "--force", r.bare, branch)
+
+	return r.git(r.workDir, "rev-parse", "HEAD")
+}
+
+func (r *repository) tag(name string) {
+	r.t.Helper()
+
+	r.git(r.workDir, "tag", name)
+	r.git(r.workDir, "push", "--quiet", r.bare, name)
+}
+
+func (r *repository) source(ref string) string {
+	src := "git+file://" + filepath.ToSlash(r.bare)
+
+	if ref != "" {
+		src += "#" + ref
+	}
+
+	return src
+}
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		source        string
+		expectedRepo  string
+		expectedRef   string
+		expectedError string
+	}{
+		{
+			scenario:     "https without ref",
+			source:       "git+https://example.org/my-plugin.git",
+			expectedRepo: "https://example.org/my-plugin.git",
+			expectedRef:  "HEAD",
+		},
+		{
+			scenario:     "file with tag",
+			source:       "git+file:///tmp/my-plugin.git#v1.0.0",
+			expectedRepo: "file:///tmp/my-plugin.git",
+			expectedRef:  "v1.0.0",
+		},
+		{
+			scenario:     "branch",
+			source:       "git+https://example.org/my-plugin.git#feature/foo",
+			expectedRepo: "https://example.org/my-plugin.git",
+			expectedRef:  "feature/foo",
+		},
+		{
+			scenario:      "no prefix",
+			source:        "https://example.org/my-plugin.git",
+			expectedError: `invalid git source: "https://example.org/my-plugin.git"`,
+		},
+		{
+			scenario:      "unsupported scheme",
+			source:        "git+ftp://example.org/my-plugin.git",
+			expectedError: `invalid git source: "git+ftp://example.org/my-plugin.git"`,
+		},
+		{
+			scenario:      "no repository",
+			source:        "git+https://#main",
+			expectedError: `invalid git source: "git+https://#main"`,
+		},
+		{
+			scenario:      "empty ref",
+			source:        "git+https://example.org/my-plugin.git#",
+			expectedError: `invalid git ref: ""`,
+		},
+		{
+			scenario:      "option as ref",
+			source:        "git+https://example.org/my-plugin.git#--output=/tmp/x",
+			expectedError: `invalid git ref: "--output=/tmp/x"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			repo, ref, err := gitinstaller.ParseSource(tc.source)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRepo, repo)
+			assert.Equal(t, tc.expectedRef, ref)
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, gitinstaller.IsValid(context.Background(), "git+https://example.org/my-plugin.git#v1.0.0"))
+	assert.True(t, gitinstaller.IsValid(context.Background(), "git+file:///tmp/my-plugin.git"))
+	assert.False(t, gitinstaller.IsValid(context.Background(), "https://example.org/my-plugin.git"))
+	assert.False(t, gitinstaller.IsValid(context.Background(), "/tmp/my-plugin.git"))
+}
+
+func TestInstaller_Install(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	v1 := repo.commit("main", "v1.0.0", "#!/bin/bash\necho v1\n")
+	repo.tag("v1.0.0")
+	v2 := repo.commit("main", "v2.0.0", "#!/bin/bash\necho v2\n")
+	dev := repo.commit("develop", "v3.0.0-dev", "#!/bin/bash\necho dev\n")
+
+	testCases := []struct {
+		scenario         string
+		ref              string
+		expectedVersion  string
+		expectedRevision string
+	}{
+		{scenario: "default branch", expectedVersion: "v2.0.0", expectedRevision: v2},
+		{scenario: "tag", ref: "v1.0.0", expectedVersion: "v1.0.0", expectedRevision: v1},
+		{scenario: "branch", ref: "develop", expectedVersion: "v3.0.0-dev", expectedRevision: dev},
+		{scenario: "commit", ref: v1, expectedVersion: "v1.0.0", expectedRevision: v1},
+		{scenario: "short commit", ref: v1[:10], expectedVersion: "v1.0.0", expectedRevision: v1},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			p, err := gitinstaller.New(fs).Install(context.Background(), "/dest", repo.source(tc.ref))
+			require.NoError(t, err)
+
+			assert.Equal(t, "my-plugin", p.Name)
+			assert.Equal(t, tc.expectedVersion, p.Version)
+			assert.Equal(t, tc.expectedRevision, p.Revision)
+
+			fi, err := fs.Stat("/dest/my-plugin/my-plugin.sh")
+			require.NoError(t, err)
+
+			assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+
+			// The history is not exported.
+			exists, err := afero.DirExists(fs, "/dest/my-plugin/.git")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestInstaller_Install_Error(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	repo.commit("main", "v1.0.0", "#!/bin/bash\necho v1\n")
+
+	testCases := []struct {
+		scenario      string
+		source        string
+		expectedError string
+	}{
+		{
+			scenario:      "invalid source",
+			source:        "git+ftp://example.org/my-plugin.git",
+			expectedError: "invalid git source",
+		},
+		{
+			scenario:      "repository not found",
+			source:        "git+file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "unknown.git")),
+			expectedError: "git command failed",
+		},
+		{
+			scenario:      "ref not found",
+			source:        repo.source("v9.9.9"),
+			expectedError: "could not resolve ref: git command failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			p, err := gitinstaller.New(fs).Install(context.Background(), "/dest", tc.source)
+
+			assert.Nil(t, p)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+
+			exists, err := afero.Exists(fs, "/dest/my-plugin")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestRegistry_Upgrade(t *testing.T) {
+	t.Parallel()
+
+	repo := newRepository(t)
+	repo.commit("main", "v1.0.0", "#!/bin/bash\necho v1\n")
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), repo.source("main")))
+
+	// The same commit is not an upgrade.
+	require.NoError(t, r.Upgrade(context.Background(), "my-plugin"))
+
+	// A new commit of the same version is an upgrade.
+	fix := repo.commit("main", "v1.0.0", "#!/bin/bash\necho fixed\n")
+
+	require.NoError(t, r.Upgrade(context.Background(), "my-plugin"))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+	assert.Equal(t, fix, p.Revision)
+	assert.Equal(t, repo.source("main"), p.Source)
+
+	script, err := afero.ReadFile(fs, "/plugins/my-plugin/my-plugin.sh")
+	require.NoError(t, err)
+
+	assert.Equal(t, "#!/bin/bash\necho fixed\n", string(script))
+}
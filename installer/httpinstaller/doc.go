@@ -0,0 +1,5 @@
+// Package httpinstaller provides an installer for the plugins served over http or https. It is registered as "http"
+// when the package is imported, use Register() to configure it.
+//
+//	import _ "github.com/nhatthm/plugin-registry/installer/httpinstaller"
+package httpinstaller
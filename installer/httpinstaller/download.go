@@ -0,0 +1,125 @@
+package httpinstaller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+)
+
+// ErrUnexpectedStatus indicates that the server did not respond with 200 OK or 206 Partial Content.
+var ErrUnexpectedStatus = errors.New("unexpected status")
+
+// statusError is an unexpected status, it is retried if the server may recover.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: %d %s", ErrUnexpectedStatus, e.code, http.StatusText(e.code))
+}
+
+func (e *statusError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func (e *statusError) temporary() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= http.StatusInternalServerError
+}
+
+// download downloads the url into the file. When the download is interrupted, it is resumed with a range request if
+// the server supports it, or restarted otherwise, up to the number of retries.
+func (i *Installer) download(ctx context.Context, url string, f afero.File) error {
+	var (
+		offset int64
+		err    error
+	)
+
+	delay := i.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		offset, err = i.fetch(ctx, url, f, offset)
+		if err == nil {
+			return nil
+		}
+
+		var se *statusError
+
+		if ctx.Err() != nil || (errors.As(err, &se) && !se.temporary()) || attempt >= i.retries {
+			return ctxd.WrapError(ctx, err, "could not download file", "url", url, "attempts", attempt+1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctxd.WrapError(ctx, ctx.Err(), "could not download file", "url", url)
+
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// fetch downloads the url from the offset into the file and returns the new offset.
+func (i *Installer) fetch(ctx context.Context, url string, f afero.File, offset int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return offset, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return offset, err
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 && rangeStart(resp) == offset:
+
+	case resp.StatusCode == http.StatusOK:
+		// The server does not support range requests, start over.
+		if offset, err = 0, f.Truncate(0); err != nil {
+			return 0, err
+		}
+
+	default:
+		return offset, &statusError{code: resp.StatusCode}
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	n, err := io.Copy(f, resp.Body)
+
+	return offset + n, err
+}
+
+// rangeStart returns the first byte of a partial content, from "Content-Range: bytes <start>-<end>/<size>".
+func rangeStart(resp *http.Response) int64 {
+	r := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+
+	pos := strings.Index(r, "-")
+	if pos < 0 {
+		return -1
+	}
+
+	start, err := strconv.ParseInt(r[:pos], 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return start
+}
@@ -0,0 +1,285 @@
+package httpinstaller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// Name is the name of the installer.
+const Name = "http"
+
+const (
+	defaultRetries    = 3
+	defaultRetryDelay = time.Second
+)
+
+var _ installer.Installer = (*Installer)(nil)
+
+func init() { //nolint: gochecknoinits
+	Register()
+}
+
+// Option configures Installer.
+type Option func(i *Installer)
+
+// Installer installs the plugins from a http or https url. The url is either an archive, such as
+// "https://example.org/my-plugin-1.0.0-linux-amd64.tar.gz", or the directory of the plugin that has the metadata file,
+// such as "https://example.org/my-plugin/", then the runtime artifact of the metadata is downloaded.
+type Installer struct {
+	fs     afero.Fs
+	client *http.Client
+
+	retries        int
+	retryDelay     time.Duration
+	extractOptions []archiveinstaller.Option
+}
+
+// Install downloads the plugin into "<dest>/<name>". The checksum of the source in the request, if any, is verified
+// against the downloaded file.
+func (i *Installer) Install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	req := installer.RequestFromContext(ctx, src)
+
+	p, err := i.install(ctx, dest, src, req.SourceChecksum)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	return p, nil
+}
+
+func (i *Installer) install(ctx context.Context, dest, src, checksum string) (*plugin.Plugin, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if format, ok := archiveinstaller.DetectFormat(u.Path); ok {
+		return i.installArchive(ctx, dest, src, format, checksum)
+	}
+
+	base := *u
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/"
+
+	metadata, err := i.get(ctx, base.ResolveReference(&url.URL{Path: plugin.MetadataFile}).String())
+	if err != nil {
+		return nil, err
+	}
+
+	var p plugin.Plugin
+
+	if err := yaml.Unmarshal(metadata, &p); err != nil {
+		return nil, err
+	}
+
+	if !plugin.IsValidName(p.Name) {
+		return nil, ctxd.WrapError(ctx, plugin.ErrInvalidName, "could not download plugin", "name", p.Name)
+	}
+
+	a := p.ResolveArtifact(p.RuntimeArtifact())
+	artifactURL := base.ResolveReference(&url.URL{Path: a.File}).String()
+
+	if format, ok := archiveinstaller.DetectFormat(a.File); ok {
+		return i.installArchive(ctx, dest, artifactURL, format, checksum)
+	}
+
+	return i.installBinary(ctx, dest, base, p, a, metadata, checksum)
+}
+
+func (i *Installer) installArchive(ctx context.Context, dest, src string, format archiveinstaller.Format, checksum string) (*plugin.Plugin, error) {
+	f, cleanup, err := i.downloadTemp(ctx, dest, src, checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cleanup()
+
+	return archiveinstaller.Unpack(i.fs, dest, format, f, i.extractOptions...)
+}
+
+func (i *Installer) installBinary(
+	ctx context.Context,
+	dest string,
+	base url.URL,
+	p plugin.Plugin,
+	a plugin.Artifact,
+	metadata []byte,
+	checksum string,
+) (*plugin.Plugin, error) {
+	pluginDir := filepath.Join(dest, p.Name)
+
+	path, err := p.RuntimeArtifactPath(pluginDir)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not download artifact", "name", p.Name)
+	}
+
+	if err := i.fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := i.fs.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.download(ctx, base.ResolveReference(&url.URL{Path: a.File}).String(), f)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil && checksum != "" {
+		err = plugin.Artifact{File: a.File, Checksum: checksum}.Verify(i.fs, path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := afero.WriteFile(i.fs, filepath.Join(pluginDir, plugin.MetadataFile), metadata, 0o644); err != nil {
+		return nil, err
+	}
+
+	// The signature is optional, the registry decides whether the plugin must be signed.
+	if sig, err := i.get(ctx, base.ResolveReference(&url.URL{Path: plugin.SignatureFile}).String()); err == nil {
+		if err := afero.WriteFile(i.fs, filepath.Join(pluginDir, plugin.SignatureFile), sig, 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	return plugin.Load(i.fs, pluginDir)
+}
+
+// downloadTemp downloads the url into a temporary file in the destination and verifies its checksum, if any.
+func (i *Installer) downloadTemp(ctx context.Context, dest, src, checksum string) (afero.File, func(), error) {
+	if err := i.fs.MkdirAll(dest, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := afero.TempFile(i.fs, dest, ".download-*"+path.Ext(src))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		_ = f.Close()             //nolint: errcheck
+		_ = i.fs.Remove(f.Name()) //nolint: errcheck
+	}
+
+	if err = i.download(ctx, src, f); err == nil && checksum != "" {
+		err = plugin.Artifact{File: src, Checksum: checksum}.Verify(i.fs, f.Name())
+	}
+
+	if err == nil {
+		_, err = f.Seek(0, 0)
+	}
+
+	if err != nil {
+		cleanup()
+
+		return nil, nil, err
+	}
+
+	return f, cleanup, nil
+}
+
+// get downloads a small file in memory.
+func (i *Installer) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not download file", "url", u)
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ctxd.WrapError(ctx, &statusError{code: resp.StatusCode}, "could not download file", "url", u)
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not download file", "url", u)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// New creates a new installer.
+func New(fs afero.Fs, options ...Option) *Installer {
+	i := &Installer{
+		fs:         fs,
+		client:     http.DefaultClient,
+		retries:    defaultRetries,
+		retryDelay: defaultRetryDelay,
+	}
+
+	for _, o := range options {
+		o(i)
+	}
+
+	return i
+}
+
+// Register registers the installer with the options, it replaces the installer registered with the default options
+// when the package is imported.
+//
+//	httpinstaller.Register(httpinstaller.WithClient(client), httpinstaller.WithRetries(5, time.Second))
+func Register(options ...Option) {
+	installer.Register(Name, IsValid, func(fs afero.Fs) installer.Installer {
+		return New(fs, options...)
+	})
+}
+
+// IsValid checks whether the source is a http or https url.
+func IsValid(_ context.Context, src string) bool {
+	u, err := url.Parse(src)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// WithClient sets the http client, for example to use a proxy or to add the authentication headers. The default is
+// http.DefaultClient.
+func WithClient(c *http.Client) Option {
+	return func(i *Installer) {
+		i.client = c
+	}
+}
+
+// WithRetries sets the number of times to retry an interrupted download, and the delay before the first retry, that
+// doubles after each one. The default is 3 retries after 1 second.
+func WithRetries(retries int, delay time.Duration) Option {
+	return func(i *Installer) {
+		i.retries = retries
+		i.retryDelay = delay
+	}
+}
+
+// WithExtractOptions sets the options to extract the archives.
+func WithExtractOptions(options ...archiveinstaller.Option) Option {
+	return func(i *Installer) {
+		i.extractOptions = append(i.extractOptions, options...)
+	}
+}
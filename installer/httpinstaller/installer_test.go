@@ -0,0 +1,442 @@
+package httpinstaller_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/httpinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+var metadata = fmt.Sprintf(`name: my-plugin
+version: v1.0.0
+artifacts:
+    %s/%s:
+        file: bin/my-plugin
+`, runtime.GOOS, runtime.GOARCH)
+
+const binary = "#!/bin/bash\necho hello\n"
+
+type server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	files     map[string][]byte
+	requests  []string
+	failures  map[string][]func(w http.ResponseWriter, r *http.Request)
+	noRange   bool
+	authToken string
+}
+
+func newServer(t *testing.T) *server {
+	t.Helper()
+
+	s := &server{
+		files:    map[string][]byte{},
+		failures: map[string][]func(w http.ResponseWriter, r *http.Request){},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+
+	s.requests = append(s.requests, r.URL.Path+" "+r.Header.Get("Range"))
+	data, ok := s.files[r.URL.Path]
+
+	var fail func(w http.ResponseWriter, r *http.Request)
+
+	if f := s.failures[r.URL.Path]; len(f) > 0 {
+		fail, s.failures[r.URL.Path] = f[0], f[1:]
+	}
+
+	s.mu.Unlock()
+
+	switch {
+	case s.authToken != "" && r.Header.Get("Authorization") != "Bearer "+s.authToken:
+		w.WriteHeader(http.StatusUnauthorized)
+
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+
+	case fail != nil:
+		fail(w, r)
+
+	case s.noRange:
+		_, _ = w.Write(data) //nolint: errcheck
+
+	default:
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func (s *server) interrupt(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := s.files[path]
+
+	s.failures[path] = append(s.failures[path], func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write(data[:len(data)/2]) //nolint: errcheck
+
+		w.(http.Flusher).Flush()
+
+		panic(http.ErrAbortHandler)
+	})
+}
+
+func (s *server) fail(path string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[path] = append(s.failures[path], func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(code)
+	})
+}
+
+func makeTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range []string{"my-plugin/" + plugin.MetadataFile, "my-plugin/bin/my-plugin"} {
+		body, ok := files[name]
+		if !ok {
+			continue
+		}
+
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body))}))
+
+		_, err := tw.Write([]byte(body))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func archive(t *testing.T) []byte {
+	t.Helper()
+
+	// Make the archive big enough to be interrupted in the middle.
+	return makeTarGz(t, map[string]string{
+		"my-plugin/" + plugin.MetadataFile: metadata,
+		"my-plugin/bin/my-plugin":          binary + "# " + strings.Repeat("x", 64*1024) + "\n",
+	})
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func install(ctx context.Context, t *testing.T, fs afero.Fs, src string, options ...httpinstaller.Option) (*plugin.Plugin, error) {
+	t.Helper()
+
+	options = append([]httpinstaller.Option{httpinstaller.WithRetries(3, time.Millisecond)}, options...)
+
+	return httpinstaller.New(fs, options...).Install(ctx, "/dest", src)
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, httpinstaller.IsValid(context.Background(), "https://example.org/my-plugin.tar.gz"))
+	assert.True(t, httpinstaller.IsValid(context.Background(), "http://example.org/my-plugin/"))
+	assert.False(t, httpinstaller.IsValid(context.Background(), "https:///my-plugin"))
+	assert.False(t, httpinstaller.IsValid(context.Background(), "ftp://example.org/my-plugin"))
+	assert.False(t, httpinstaller.IsValid(context.Background(), "./my-plugin"))
+}
+
+func TestInstaller_Install_Archive(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.files["/my-plugin-1.0.0.tar.gz"] = archive(t)
+
+	fs := afero.NewMemMapFs()
+
+	p, err := install(context.Background(), t, fs, s.URL+"/my-plugin-1.0.0.tar.gz")
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-plugin", p.Name)
+	assert.Equal(t, "v1.0.0", p.Version)
+
+	entries, err := afero.ReadDir(fs, "/dest")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "my-plugin", entries[0].Name())
+}
+
+func TestInstaller_Install_Resume(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario         string
+		noRange          bool
+		expectedRequests []string
+	}{
+		{
+			scenario: "resume",
+			expectedRequests: []string{
+				"/my-plugin-1.0.0.tar.gz ",
+				fmt.Sprintf("/my-plugin-1.0.0.tar.gz bytes=%d-", len(archive(t))/2),
+			},
+		},
+		{
+			scenario: "restart",
+			noRange:  true,
+			expectedRequests: []string{
+				"/my-plugin-1.0.0.tar.gz ",
+				fmt.Sprintf("/my-plugin-1.0.0.tar.gz bytes=%d-", len(archive(t))/2),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			data := archive(t)
+
+			s := newServer(t)
+			s.noRange = tc.noRange
+			s.files["/my-plugin-1.0.0.tar.gz"] = data
+			s.interrupt("/my-plugin-1.0.0.tar.gz")
+
+			ctx := installer.WithRequest(context.Background(), installer.Request{
+				Source:         s.URL + "/my-plugin-1.0.0.tar.gz",
+				SourceChecksum: checksum(data),
+			})
+
+			p, err := install(ctx, t, afero.NewMemMapFs(), s.URL+"/my-plugin-1.0.0.tar.gz")
+			require.NoError(t, err)
+
+			assert.Equal(t, "my-plugin", p.Name)
+			assert.Equal(t, tc.expectedRequests, s.requests)
+		})
+	}
+}
+
+func TestInstaller_Install_Retry(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.files["/my-plugin-1.0.0.tar.gz"] = archive(t)
+	s.fail("/my-plugin-1.0.0.tar.gz", http.StatusServiceUnavailable)
+	s.fail("/my-plugin-1.0.0.tar.gz", http.StatusTooManyRequests)
+
+	_, err := install(context.Background(), t, afero.NewMemMapFs(), s.URL+"/my-plugin-1.0.0.tar.gz")
+	require.NoError(t, err)
+
+	assert.Len(t, s.requests, 3)
+}
+
+func TestInstaller_Install_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario         string
+		setup            func(s *server)
+		source           string
+		checksum         string
+		expectedError    error
+		expectedRequests int
+	}{
+		{
+			scenario:         "not found",
+			source:           "/unknown.tar.gz",
+			expectedError:    httpinstaller.ErrUnexpectedStatus,
+			expectedRequests: 1,
+		},
+		{
+			scenario: "too many failures",
+			setup: func(s *server) {
+				for i := 0; i < 4; i++ {
+					s.fail("/my-plugin-1.0.0.tar.gz", http.StatusBadGateway)
+				}
+			},
+			source:           "/my-plugin-1.0.0.tar.gz",
+			expectedError:    httpinstaller.ErrUnexpectedStatus,
+			expectedRequests: 4,
+		},
+		{
+			scenario:         "checksum mismatch",
+			source:           "/my-plugin-1.0.0.tar.gz",
+			checksum:         checksum([]byte("unknown")),
+			expectedError:    plugin.ErrIntegrity,
+			expectedRequests: 1,
+		},
+		{
+			scenario:         "no metadata",
+			source:           "/unknown/",
+			expectedError:    httpinstaller.ErrUnexpectedStatus,
+			expectedRequests: 1,
+		},
+		{
+			scenario: "artifact outside of plugin directory",
+			setup: func(s *server) {
+				s.files["/outside/"+plugin.MetadataFile] = []byte(strings.Replace(metadata, "bin/my-plugin", "../other-plugin", 1))
+			},
+			source:           "/outside/",
+			expectedError:    plugin.ErrInvalidArtifactPath,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			s := newServer(t)
+			s.files["/my-plugin-1.0.0.tar.gz"] = archive(t)
+
+			if tc.setup != nil {
+				tc.setup(s)
+			}
+
+			ctx := installer.WithRequest(context.Background(), installer.Request{
+				Source:         s.URL + tc.source,
+				SourceChecksum: tc.checksum,
+			})
+
+			fs := afero.NewMemMapFs()
+
+			p, err := install(ctx, t, fs, s.URL+tc.source)
+
+			assert.Nil(t, p)
+			require.ErrorIs(t, err, tc.expectedError)
+			assert.Len(t, s.requests, tc.expectedRequests)
+
+			entries, _ := afero.ReadDir(fs, "/dest") //nolint: errcheck
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestInstaller_Install_Metadata(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.files["/my-plugin/"+plugin.MetadataFile] = []byte(metadata)
+	s.files["/my-plugin/"+plugin.SignatureFile] = []byte("signature\n")
+	s.files["/my-plugin/bin/my-plugin"] = []byte(binary)
+
+	fs := afero.NewMemMapFs()
+
+	p, err := install(context.Background(), t, fs, s.URL+"/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-plugin", p.Name)
+
+	data, err := afero.ReadFile(fs, "/dest/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+	assert.Equal(t, binary, string(data))
+
+	fi, err := fs.Stat("/dest/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+
+	for _, file := range []string{plugin.MetadataFile, plugin.SignatureFile} {
+		ok, err := afero.Exists(fs, "/dest/my-plugin/"+file)
+		require.NoError(t, err)
+		assert.True(t, ok, file)
+	}
+}
+
+func TestInstaller_Install_MetadataArchive(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.files["/my-plugin/"+plugin.MetadataFile] = []byte(`name: my-plugin
+version: v1.0.0
+`)
+	s.files[fmt.Sprintf("/my-plugin/my-plugin-v1.0.0-%s-%s.tar.gz", runtime.GOOS, runtime.GOARCH)] = archive(t)
+
+	p, err := install(context.Background(), t, afero.NewMemMapFs(), s.URL+"/my-plugin/")
+	require.NoError(t, err)
+
+	assert.Equal(t, "bin/my-plugin", p.RuntimeArtifact().File)
+}
+
+func TestInstaller_Install_Client(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.authToken = "secret"
+	s.files["/my-plugin-1.0.0.tar.gz"] = archive(t)
+
+	_, err := install(context.Background(), t, afero.NewMemMapFs(), s.URL+"/my-plugin-1.0.0.tar.gz")
+	require.ErrorIs(t, err, httpinstaller.ErrUnexpectedStatus)
+
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Header.Set("Authorization", "Bearer secret")
+
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	_, err = install(context.Background(), t, afero.NewMemMapFs(), s.URL+"/my-plugin-1.0.0.tar.gz", httpinstaller.WithClient(client))
+	require.NoError(t, err)
+}
+
+func TestRegistry_Install(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t)
+	s.files["/my-plugin-1.0.0.tar.gz"] = archive(t)
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), s.URL+"/my-plugin-1.0.0.tar.gz"))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+	assert.Equal(t, s.URL+"/my-plugin-1.0.0.tar.gz", p.Source)
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
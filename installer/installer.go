@@ -3,6 +3,8 @@ package installer
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/spf13/afero"
@@ -24,7 +26,12 @@ var (
 	installers = map[string]metadata{}
 )
 
+// DefaultPriority is the priority of the installers registered with Register().
+const DefaultPriority = 0
+
 type metadata struct {
+	name      string
+	priority  int
 	validate  Validity
 	construct Constructor
 }
@@ -48,12 +55,20 @@ func (f CallbackInstaller) Install(ctx context.Context, dest, src string) (*plug
 	return f(ctx, dest, src)
 }
 
-// Register registers a plugin installer.
+// Register registers a plugin installer with the default priority.
 func Register(name string, validity Validity, constructor Constructor) {
+	RegisterWithPriority(name, DefaultPriority, validity, constructor)
+}
+
+// RegisterWithPriority registers a plugin installer with a priority. When several installers support a source, the one
+// with the highest priority is used, the installers with the same priority are ordered by name.
+func RegisterWithPriority(name string, priority int, validity Validity, constructor Constructor) {
 	installersMu.Lock()
 	defer installersMu.Unlock()
 
 	installers[name] = metadata{
+		name:      name,
+		priority:  priority,
 		validate:  validity,
 		construct: constructor,
 	}
@@ -72,16 +87,85 @@ func New(ctx context.Context, name string) (Installer, error) {
 	return m.construct(fsCtx.Fs(ctx)), nil
 }
 
-// Find finds an installer for the given plugin url.
+// Find finds an installer for the given plugin url. See Resolve().
 func Find(ctx context.Context, src string) (Installer, error) {
+	i, _, err := Resolve(ctx, src)
+
+	return i, err
+}
+
+// Resolve finds an installer for the given plugin url, and returns the source to pass to it. A source prefixed by the
+// name of an installer and a colon, such as "fs:./my-plugin" or "github:example/my-plugin", is installed by that
+// installer without the prefix. Otherwise, the installer with the highest priority that supports the source is used.
+func Resolve(ctx context.Context, src string) (Installer, string, error) {
 	installersMu.Lock()
 	defer installersMu.Unlock()
 
-	for _, m := range installers {
+	if m, rest, ok := explicitInstaller(src); ok {
+		return m.construct(fsCtx.Fs(ctx)), rest, nil
+	}
+
+	for _, m := range sortedInstallers() {
 		if m.validate(ctx, src) {
-			return m.construct(fsCtx.Fs(ctx)), nil
+			return m.construct(fsCtx.Fs(ctx)), src, nil
 		}
 	}
 
-	return nil, ErrNoInstaller
+	return nil, "", ErrNoInstaller
+}
+
+// Matches returns the names of the installers that support the source, in the order they are tried. It helps to debug
+// which installer is used.
+func Matches(ctx context.Context, src string) []string {
+	installersMu.Lock()
+	defer installersMu.Unlock()
+
+	if m, _, ok := explicitInstaller(src); ok {
+		return []string{m.name}
+	}
+
+	result := make([]string, 0, len(installers))
+
+	for _, m := range sortedInstallers() {
+		if m.validate(ctx, src) {
+			result = append(result, m.name)
+		}
+	}
+
+	return result
+}
+
+// explicitInstaller finds the installer in the prefix of the source, such as "fs:./my-plugin". A url, such as
+// "https://example.org", is not a prefix.
+func explicitInstaller(src string) (metadata, string, bool) {
+	pos := strings.Index(src, ":")
+	if pos <= 0 || strings.HasPrefix(src[pos+1:], "//") {
+		return metadata{}, "", false
+	}
+
+	m, ok := installers[src[:pos]]
+	if !ok {
+		return metadata{}, "", false
+	}
+
+	return m, src[pos+1:], true
+}
+
+// sortedInstallers returns the installers by priority, then by name. The lock must be held.
+func sortedInstallers() []metadata {
+	result := make([]metadata, 0, len(installers))
+
+	for _, m := range installers {
+		result = append(result, m)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].priority != result[j].priority {
+			return result[i].priority > result[j].priority
+		}
+
+		return result[i].name < result[j].name
+	})
+
+	return result
 }
@@ -96,3 +96,123 @@ func TestFind_Error(t *testing.T) {
 	assert.Nil(t, actual)
 	require.EqualError(t, err, expected)
 }
+
+func TestRequestFromContext(t *testing.T) {
+	t.Parallel()
+
+	req := installer.Request{
+		Source:  "github.com/example/my-plugin",
+		Name:    "my-plugin",
+		Version: plugin.MustParseConstraint("^1.4"),
+	}
+
+	ctx := installer.WithRequest(context.Background(), req)
+
+	assert.Equal(t, req, installer.RequestFromContext(ctx, "github.com/example/my-plugin"))
+	assert.Equal(t, installer.Request{Source: "another-source"}, installer.RequestFromContext(ctx, "another-source"))
+	assert.Equal(t, installer.Request{Source: "my-plugin"}, installer.RequestFromContext(context.Background(), "my-plugin"))
+}
+
+func TestResolve_Priority(t *testing.T) {
+	t.Parallel()
+
+	validity := func(_ context.Context, src string) bool {
+		return src == "TestResolve_Priority"
+	}
+
+	construct := func(name string) installer.Constructor {
+		return func(afero.Fs) installer.Installer {
+			return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+				return &plugin.Plugin{Name: name}, nil
+			})
+		}
+	}
+
+	installer.RegisterWithPriority("TestResolve_Priority_B", 10, validity, construct("b"))
+	installer.RegisterWithPriority("TestResolve_Priority_A", 10, validity, construct("a"))
+	installer.RegisterWithPriority("TestResolve_Priority_High", 20, validity, construct("high"))
+	installer.Register("TestResolve_Priority_Default", validity, construct("default"))
+
+	expected := []string{
+		"TestResolve_Priority_High",
+		"TestResolve_Priority_A",
+		"TestResolve_Priority_B",
+		"TestResolve_Priority_Default",
+	}
+
+	ctx := fsCtx.WithFs(context.Background(), afero.NewMemMapFs())
+
+	assert.Equal(t, expected, installer.Matches(ctx, "TestResolve_Priority"))
+
+	for i := 0; i < 10; i++ {
+		actual, src, err := installer.Resolve(ctx, "TestResolve_Priority")
+		require.NoError(t, err)
+
+		p, err := actual.Install(ctx, "", src)
+		require.NoError(t, err)
+
+		assert.Equal(t, "high", p.Name)
+		assert.Equal(t, "TestResolve_Priority", src)
+	}
+}
+
+func TestResolve_Explicit(t *testing.T) {
+	t.Parallel()
+
+	expected := installerMock.NoMock(t)
+
+	installer.RegisterWithPriority("TestResolve_Explicit", -10, func(context.Context, string) bool {
+		return false
+	}, func(afero.Fs) installer.Installer {
+		return expected
+	})
+
+	testCases := []struct {
+		scenario        string
+		source          string
+		expectedSource  string
+		expectedMatches []string
+		expectedError   error
+	}{
+		{
+			scenario:        "explicit",
+			source:          "TestResolve_Explicit:./my-plugin",
+			expectedSource:  "./my-plugin",
+			expectedMatches: []string{"TestResolve_Explicit"},
+		},
+		{
+			scenario:        "url",
+			source:          "TestResolve_Explicit://my-plugin",
+			expectedMatches: []string{},
+			expectedError:   installer.ErrNoInstaller,
+		},
+		{
+			scenario:        "unknown prefix",
+			source:          "TestResolve_Unknown:./my-plugin",
+			expectedMatches: []string{},
+			expectedError:   installer.ErrNoInstaller,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := fsCtx.WithFs(context.Background(), afero.NewMemMapFs())
+
+			actual, src, err := installer.Resolve(ctx, tc.source)
+
+			assert.Equal(t, tc.expectedSource, src)
+			assert.Equal(t, tc.expectedMatches, installer.Matches(ctx, tc.source))
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, actual)
+			}
+		})
+	}
+}
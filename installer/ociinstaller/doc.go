@@ -0,0 +1,7 @@
+// Package ociinstaller provides an installer for the plugins distributed as OCI artifacts, in a registry, such as
+// "oci://registry.example.org/plugins/my-plugin:1.0.0", or in a local OCI image layout, such as
+// "oci-layout:///path/to/layout:1.0.0". It is registered as "oci" when the package is imported, use Register() to
+// configure it.
+//
+//	import _ "github.com/nhatthm/plugin-registry/installer/ociinstaller"
+package ociinstaller
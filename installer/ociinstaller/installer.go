@@ -0,0 +1,335 @@
+package ociinstaller
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/archiveinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// Name is the name of the installer.
+const Name = "oci"
+
+var (
+	// ErrNoMatchingManifest indicates that the image index has no manifest for the runtime platform.
+	ErrNoMatchingManifest = errors.New("no manifest for the runtime platform")
+	// ErrLayerNotFound indicates that the manifest has no layer that is an archive of the plugin.
+	ErrLayerNotFound = errors.New("no plugin layer in manifest")
+	// ErrDigestMismatch indicates that the content does not match its digest.
+	ErrDigestMismatch = errors.New("digest mismatch")
+)
+
+var _ installer.Installer = (*Installer)(nil)
+
+func init() { //nolint: gochecknoinits
+	Register()
+}
+
+// Option configures Installer.
+type Option func(i *Installer)
+
+// Installer installs the plugins from an OCI artifact. If the reference is an image index, the manifest of the runtime
+// platform is selected, then the first layer that is a tar.gz or a zip archive is unpacked like the archive installer
+// does. The digests of the manifests and of the layer are verified.
+type Installer struct {
+	fs     afero.Fs
+	client *http.Client
+
+	plainHTTP      bool
+	extractOptions []archiveinstaller.Option
+}
+
+// Install pulls the plugin into "<dest>/<name>". The checksum of the source in the request, if any, is verified against
+// the layer.
+func (i *Installer) Install(ctx context.Context, dest, src string) (*plugin.Plugin, error) {
+	req := installer.RequestFromContext(ctx, src)
+
+	p, err := i.install(ctx, dest, src, req.SourceChecksum)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not install plugin", "source", src)
+	}
+
+	return p, nil
+}
+
+func (i *Installer) install(ctx context.Context, dest, src, checksum string) (*plugin.Plugin, error) {
+	ref, err := ParseReference(src)
+	if err != nil {
+		return nil, err
+	}
+
+	s := i.store(ref)
+
+	m, err := resolveManifest(ctx, s, ref.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	layer, format, err := selectLayer(m)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not find plugin layer", "reference", ref.String())
+	}
+
+	f, cleanup, err := i.fetchBlob(ctx, s, dest, layer, checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cleanup()
+
+	return archiveinstaller.Unpack(i.fs, dest, format, f, i.extractOptions...)
+}
+
+func (i *Installer) store(ref Reference) store {
+	if ref.IsLayout() {
+		return &layoutStore{fs: i.fs, path: ref.Repository}
+	}
+
+	scheme := "https"
+	if i.plainHTTP {
+		scheme = "http"
+	}
+
+	return &registryStore{
+		client:  i.client,
+		baseURL: scheme + "://" + ref.Host + "/v2/" + ref.Repository,
+	}
+}
+
+// fetchBlob downloads the blob into a temporary file in the destination and verifies its digest and size.
+func (i *Installer) fetchBlob(ctx context.Context, s store, dest string, d Descriptor, checksum string) (afero.File, func(), error) {
+	if err := validateDigest(d.Digest); err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.blob(ctx, d.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer r.Close() //nolint: errcheck
+
+	if err := i.fs.MkdirAll(dest, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := afero.TempFile(i.fs, dest, ".download-*")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		_ = f.Close()             //nolint: errcheck
+		_ = i.fs.Remove(f.Name()) //nolint: errcheck
+	}
+
+	if _, err = io.Copy(f, r); err == nil {
+		err = plugin.Artifact{File: d.Digest, Checksum: d.Digest, Size: d.Size}.Verify(i.fs, f.Name())
+	}
+
+	if err == nil && checksum != "" {
+		err = plugin.Artifact{File: d.Digest, Checksum: checksum}.Verify(i.fs, f.Name())
+	}
+
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		cleanup()
+
+		return nil, nil, ctxd.WrapError(ctx, err, "could not fetch blob", "digest", d.Digest)
+	}
+
+	return f, cleanup, nil
+}
+
+// resolveManifest fetches the manifest, and if it is an image index, the manifest of the runtime platform.
+func resolveManifest(ctx context.Context, s store, ref string) (Manifest, error) {
+	m, err := fetchManifest(ctx, s, ref, Descriptor{})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if !m.IsIndex() {
+		return m, nil
+	}
+
+	d, err := selectManifest(m)
+	if err != nil {
+		return Manifest{}, ctxd.WrapError(ctx, err, "could not select manifest", "reference", ref)
+	}
+
+	if m, err = fetchManifest(ctx, s, d.Digest, d); err != nil {
+		return Manifest{}, err
+	}
+
+	if m.IsIndex() {
+		return Manifest{}, ctxd.WrapError(ctx, ErrNoMatchingManifest, "could not select manifest", "reference", d.Digest)
+	}
+
+	return m, nil
+}
+
+// fetchManifest fetches and verifies a manifest, the descriptor is empty if the manifest is fetched by tag.
+func fetchManifest(ctx context.Context, s store, ref string, d Descriptor) (Manifest, error) {
+	data, mediaType, digest, err := s.manifest(ctx, ref)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if d.Digest != "" {
+		digest = d.Digest
+	}
+
+	if digest != "" {
+		if err := verifyDigest(data, digest); err != nil {
+			return Manifest{}, ctxd.WrapError(ctx, err, "could not verify manifest", "reference", ref)
+		}
+	}
+
+	if d.Size > 0 && int64(len(data)) != d.Size {
+		return Manifest{}, ctxd.WrapError(ctx, ErrDigestMismatch, "could not verify manifest", "reference", ref, "size", len(data))
+	}
+
+	var m Manifest
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, ctxd.WrapError(ctx, err, "could not parse manifest", "reference", ref)
+	}
+
+	if mediaType != "" && m.MediaType == "" {
+		m.MediaType = mediaType
+	}
+
+	if m.MediaType == "" {
+		m.MediaType = d.MediaType
+	}
+
+	return m, nil
+}
+
+// selectManifest selects the manifest of the runtime platform in an image index, or the manifest of the runtime os
+// without architecture, like the runtime artifact of a plugin.
+func selectManifest(m Manifest) (Descriptor, error) {
+	for _, id := range []plugin.ArtifactIdentifier{plugin.RuntimeArtifactIdentifier(), plugin.RuntimeArtifactIdentifierWithoutArch()} {
+		for _, d := range m.Manifests {
+			if d.Platform != nil && plugin.NewArtifactIdentifier(d.Platform.OS, d.Platform.Architecture) == id {
+				return d, nil
+			}
+		}
+	}
+
+	return Descriptor{}, ErrNoMatchingManifest
+}
+
+// selectLayer selects the first layer that is a tar.gz or a zip archive, by its media type or by its title.
+func selectLayer(m Manifest) (Descriptor, archiveinstaller.Format, error) {
+	for _, d := range m.Layers {
+		if format, ok := layerFormat(d); ok {
+			return d, format, nil
+		}
+	}
+
+	return Descriptor{}, "", ErrLayerNotFound
+}
+
+func layerFormat(d Descriptor) (archiveinstaller.Format, bool) {
+	switch {
+	case strings.HasSuffix(d.MediaType, "tar+gzip"), strings.HasSuffix(d.MediaType, "tar.gzip"):
+		return archiveinstaller.FormatTarGz, true
+
+	case d.MediaType == "application/zip", strings.HasSuffix(d.MediaType, "+zip"):
+		return archiveinstaller.FormatZip, true
+	}
+
+	return archiveinstaller.DetectFormat(d.Annotations[AnnotationTitle])
+}
+
+func verifyDigest(data []byte, digest string) error {
+	if err := validateDigest(digest); err != nil {
+		return err
+	}
+
+	var h hash.Hash
+
+	if strings.HasPrefix(digest, "sha512:") {
+		h = sha512.New()
+	} else {
+		h = sha256.New()
+	}
+
+	_, _ = h.Write(data) //nolint: errcheck
+
+	if actual := digest[:strings.Index(digest, ":")+1] + hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return ErrDigestMismatch
+	}
+
+	return nil
+}
+
+// New creates a new installer.
+func New(fs afero.Fs, options ...Option) *Installer {
+	i := &Installer{
+		fs:     fs,
+		client: http.DefaultClient,
+	}
+
+	for _, o := range options {
+		o(i)
+	}
+
+	return i
+}
+
+// Register registers the installer with the options, it replaces the installer registered with the default options
+// when the package is imported.
+//
+//	ociinstaller.Register(ociinstaller.WithClient(client))
+func Register(options ...Option) {
+	installer.Register(Name, IsValid, func(fs afero.Fs) installer.Installer {
+		return New(fs, options...)
+	})
+}
+
+// IsValid checks whether the source is a reference to a registry, such as "oci://host/repository:tag", or to a local
+// layout, such as "oci-layout:///path/to/layout:tag".
+func IsValid(_ context.Context, src string) bool {
+	_, err := ParseReference(src)
+
+	return err == nil
+}
+
+// WithClient sets the http client, for example to add the authentication headers. The default is http.DefaultClient.
+func WithClient(c *http.Client) Option {
+	return func(i *Installer) {
+		i.client = c
+	}
+}
+
+// WithPlainHTTP uses http instead of https to connect to the registries, for example to a local registry.
+func WithPlainHTTP() Option {
+	return func(i *Installer) {
+		i.plainHTTP = true
+	}
+}
+
+// WithExtractOptions sets the options to extract the layers.
+func WithExtractOptions(options ...archiveinstaller.Option) Option {
+	return func(i *Installer) {
+		i.extractOptions = append(i.extractOptions, options...)
+	}
+}
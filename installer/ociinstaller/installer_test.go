@@ -0,0 +1,484 @@
+package ociinstaller_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/installer/ociinstaller"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const mediaTypeLayer = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+// artifact is the content of an OCI artifact, the blobs are indexed by digest.
+type artifact struct {
+	blobs map[string][]byte
+	tags  map[string]ociinstaller.Descriptor
+}
+
+func newArtifact() *artifact {
+	return &artifact{
+		blobs: map[string][]byte{},
+		tags:  map[string]ociinstaller.Descriptor{},
+	}
+}
+
+func (a *artifact) add(mediaType string, data []byte) ociinstaller.Descriptor {
+	d := ociinstaller.Descriptor{MediaType: mediaType, Digest: digest(data), Size: int64(len(data))}
+
+	a.blobs[d.Digest] = data
+
+	return d
+}
+
+func (a *artifact) addJSON(t *testing.T, mediaType string, v interface{}) ociinstaller.Descriptor {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return a.add(mediaType, data)
+}
+
+// addManifest adds a manifest of the layers.
+func (a *artifact) addManifest(t *testing.T, layers ...ociinstaller.Descriptor) ociinstaller.Descriptor {
+	t.Helper()
+
+	return a.addJSON(t, ociinstaller.MediaTypeImageManifest, ociinstaller.Manifest{
+		MediaType: ociinstaller.MediaTypeImageManifest,
+		Layers:    layers,
+	})
+}
+
+// addIndex adds an index of the manifests, per platform.
+func (a *artifact) addIndex(t *testing.T, manifests map[string]ociinstaller.Descriptor) ociinstaller.Descriptor {
+	t.Helper()
+
+	idx := ociinstaller.Manifest{MediaType: ociinstaller.MediaTypeImageIndex}
+
+	for platform, d := range manifests {
+		parts := strings.SplitN(platform, "/", 2)
+		d.Platform = &ociinstaller.Platform{OS: parts[0], Architecture: parts[1]}
+
+		idx.Manifests = append(idx.Manifests, d)
+	}
+
+	return a.addJSON(t, ociinstaller.MediaTypeImageIndex, idx)
+}
+
+// writeLayout writes the artifact as an OCI image layout.
+func (a *artifact) writeLayout(t *testing.T, fs afero.Fs, path string) {
+	t.Helper()
+
+	idx := ociinstaller.Manifest{MediaType: ociinstaller.MediaTypeImageIndex}
+
+	for tag, d := range a.tags {
+		d.Annotations = map[string]string{ociinstaller.AnnotationRefName: tag}
+
+		idx.Manifests = append(idx.Manifests, d)
+	}
+
+	data, err := json.Marshal(idx)
+	require.NoError(t, err)
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(path, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(path, "index.json"), data, 0o644))
+
+	for digest, blob := range a.blobs {
+		parts := strings.SplitN(digest, ":", 2)
+
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(path, "blobs", parts[0], parts[1]), blob, 0o644))
+	}
+}
+
+// server is an in-process registry that serves the artifact of a repository.
+type server struct {
+	*httptest.Server
+
+	artifact   *artifact
+	repository string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newServer(t *testing.T, repository string, a *artifact) *server {
+	t.Helper()
+
+	s := &server{artifact: a, repository: repository}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	s.mu.Unlock()
+
+	prefix := "/v2/" + s.repository + "/"
+
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+
+	switch {
+	case len(parts) != 2:
+		w.WriteHeader(http.StatusNotFound)
+
+	case parts[0] == "manifests":
+		d, ok := s.artifact.tags[parts[1]]
+		if !ok {
+			d, ok = s.descriptor(parts[1])
+		}
+
+		if !ok || !strings.Contains(r.Header.Get("Accept"), d.MediaType) {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", d.MediaType)
+		w.Header().Set("Docker-Content-Digest", d.Digest)
+		_, _ = w.Write(s.artifact.blobs[d.Digest]) //nolint: errcheck
+
+	case parts[0] == "blobs":
+		data, ok := s.artifact.blobs[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(data) //nolint: errcheck
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// descriptor finds the descriptor of a manifest by digest.
+func (s *server) descriptor(digest string) (ociinstaller.Descriptor, bool) {
+	data, ok := s.artifact.blobs[digest]
+	if !ok {
+		return ociinstaller.Descriptor{}, false
+	}
+
+	var m ociinstaller.Manifest
+
+	if err := json.Unmarshal(data, &m); err != nil || m.MediaType == "" {
+		return ociinstaller.Descriptor{}, false
+	}
+
+	return ociinstaller.Descriptor{MediaType: m.MediaType, Digest: digest}, true
+}
+
+func (s *server) host() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func layer(t *testing.T, version string) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"my-plugin/" + plugin.MetadataFile: fmt.Sprintf("name: my-plugin\nversion: %s\nartifacts:\n    %s:\n        file: bin/my-plugin\n",
+			version, plugin.RuntimeArtifactIdentifier()),
+		"my-plugin/bin/my-plugin": "#!/bin/bash\necho hello\n",
+	}
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range []string{"my-plugin/" + plugin.MetadataFile, "my-plugin/bin/my-plugin"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(files[name]))}))
+
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+// multiPlatform creates an artifact tagged "1.0.0" with an index of the runtime platform, whose version is "v1.0.0",
+// and of another platform, whose version is "v0.0.0".
+func multiPlatform(t *testing.T) *artifact {
+	t.Helper()
+
+	a := newArtifact()
+
+	other := "windows/arm64"
+	if runtime.GOOS == "windows" {
+		other = "linux/arm64"
+	}
+
+	a.tags["1.0.0"] = a.addIndex(t, map[string]ociinstaller.Descriptor{
+		other: a.addManifest(t, a.add(mediaTypeLayer, layer(t, "v0.0.0"))),
+		plugin.RuntimeArtifactIdentifier().String(): a.addManifest(t, a.add(mediaTypeLayer, layer(t, "v1.0.0"))),
+	})
+
+	return a
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, ociinstaller.IsValid(context.Background(), "oci://example.org/my-plugin:1.0.0"))
+	assert.True(t, ociinstaller.IsValid(context.Background(), "oci-layout:///tmp/layout:1.0.0"))
+	assert.False(t, ociinstaller.IsValid(context.Background(), "oci://example.org"))
+	assert.False(t, ociinstaller.IsValid(context.Background(), "https://example.org/my-plugin"))
+	assert.False(t, ociinstaller.IsValid(context.Background(), "/tmp/my-plugin"))
+}
+
+func TestInstaller_Install_Layout(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	multiPlatform(t).writeLayout(t, fs, "/layout")
+
+	p, err := ociinstaller.New(fs).Install(context.Background(), "/dest", "oci-layout:///layout:1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-plugin", p.Name)
+	assert.Equal(t, "v1.0.0", p.Version)
+
+	data, err := afero.ReadFile(fs, "/dest/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "#!/bin/bash\necho hello\n", string(data))
+
+	// The temporary files are removed.
+	entries, err := afero.ReadDir(fs, "/dest")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestInstaller_Install_Registry(t *testing.T) {
+	t.Parallel()
+
+	s := newServer(t, "plugins/my-plugin", multiPlatform(t))
+
+	p, err := ociinstaller.New(afero.NewMemMapFs(), ociinstaller.WithPlainHTTP()).
+		Install(context.Background(), "/dest", "oci://"+s.host()+"/plugins/my-plugin:1.0.0")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+	assert.Len(t, s.requests, 3)
+	assert.Equal(t, "/v2/plugins/my-plugin/manifests/1.0.0", s.requests[0])
+}
+
+func TestInstaller_Install_Digest(t *testing.T) {
+	t.Parallel()
+
+	a := newArtifact()
+	m := a.addManifest(t, a.add(mediaTypeLayer, layer(t, "v1.0.0")))
+
+	s := newServer(t, "my-plugin", a)
+
+	p, err := ociinstaller.New(afero.NewMemMapFs(), ociinstaller.WithPlainHTTP()).
+		Install(context.Background(), "/dest", "oci://"+s.host()+"/my-plugin@"+m.Digest)
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+}
+
+func TestInstaller_Install_LayerTitle(t *testing.T) {
+	t.Parallel()
+
+	a := newArtifact()
+	l := a.add("application/vnd.my-plugin.layer", layer(t, "v1.0.0"))
+	l.Annotations = map[string]string{ociinstaller.AnnotationTitle: "my-plugin.tar.gz"}
+
+	a.tags["latest"] = a.addManifest(t, a.add("application/vnd.my-plugin.config", []byte("{}")), l)
+
+	fs := afero.NewMemMapFs()
+
+	a.writeLayout(t, fs, "/layout")
+
+	p, err := ociinstaller.New(fs).Install(context.Background(), "/dest", "oci-layout:///layout")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+}
+
+func TestInstaller_Install_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		artifact      func(t *testing.T) *artifact
+		tag           string
+		expectedError string
+	}{
+		{
+			scenario:      "tag not found",
+			artifact:      multiPlatform,
+			tag:           "2.0.0",
+			expectedError: "could not resolve tag: manifest not found",
+		},
+		{
+			scenario: "no manifest for the runtime platform",
+			artifact: func(t *testing.T) *artifact {
+				t.Helper()
+
+				a := newArtifact()
+				a.tags["1.0.0"] = a.addIndex(t, map[string]ociinstaller.Descriptor{
+					"plan9/mips": a.addManifest(t, a.add(mediaTypeLayer, layer(t, "v1.0.0"))),
+				})
+
+				return a
+			},
+			tag:           "1.0.0",
+			expectedError: "could not select manifest: no manifest for the runtime platform",
+		},
+		{
+			scenario: "no plugin layer",
+			artifact: func(t *testing.T) *artifact {
+				t.Helper()
+
+				a := newArtifact()
+				a.tags["1.0.0"] = a.addManifest(t, a.add("application/octet-stream", []byte("hello")))
+
+				return a
+			},
+			tag:           "1.0.0",
+			expectedError: "could not find plugin layer: no plugin layer in manifest",
+		},
+		{
+			scenario: "tampered layer",
+			artifact: func(t *testing.T) *artifact {
+				t.Helper()
+
+				a := newArtifact()
+				l := a.add(mediaTypeLayer, layer(t, "v1.0.0"))
+				a.blobs[l.Digest] = layer(t, "v6.6.6")
+				a.tags["1.0.0"] = a.addManifest(t, l)
+
+				return a
+			},
+			tag:           "1.0.0",
+			expectedError: "could not fetch blob: artifact integrity check failed",
+		},
+		{
+			scenario: "tampered manifest",
+			artifact: func(t *testing.T) *artifact {
+				t.Helper()
+
+				a := multiPlatform(t)
+
+				for _, d := range a.blobs {
+					var idx ociinstaller.Manifest
+
+					if err := json.Unmarshal(d, &idx); err != nil || !idx.IsIndex() {
+						continue
+					}
+
+					for _, m := range idx.Manifests {
+						a.blobs[m.Digest] = append(a.blobs[m.Digest], ' ')
+					}
+				}
+
+				return a
+			},
+			tag:           "1.0.0",
+			expectedError: "could not verify manifest: digest mismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			tc.artifact(t).writeLayout(t, fs, "/layout")
+
+			p, err := ociinstaller.New(fs).Install(context.Background(), "/dest", "oci-layout:///layout:"+tc.tag)
+
+			assert.Nil(t, p)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedError)
+
+			exists, err := afero.Exists(fs, "/dest/my-plugin")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestInstaller_Install_SourceChecksum(t *testing.T) {
+	t.Parallel()
+
+	const src = "oci-layout:///layout:1.0.0"
+
+	fs := afero.NewMemMapFs()
+
+	multiPlatform(t).writeLayout(t, fs, "/layout")
+
+	ctx := installer.WithRequest(context.Background(), installer.Request{
+		Source:         src,
+		SourceChecksum: digest([]byte("other")),
+	})
+
+	p, err := ociinstaller.New(fs).Install(ctx, "/dest", src)
+
+	assert.Nil(t, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "artifact integrity check failed")
+}
+
+func TestRegistry_Install(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	multiPlatform(t).writeLayout(t, fs, "/layout")
+
+	r, err := registry.NewRegistry("/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), "oci-layout:///layout:1.0.0"))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", p.Version)
+	assert.Equal(t, "oci-layout:///layout:1.0.0", p.Source)
+}
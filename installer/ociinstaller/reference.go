@@ -0,0 +1,112 @@
+package ociinstaller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// SchemeRegistry is the scheme of the references to a registry.
+	SchemeRegistry = "oci://"
+	// SchemeLayout is the scheme of the references to a local OCI image layout.
+	SchemeLayout = "oci-layout://"
+
+	defaultTag = "latest"
+)
+
+// ErrInvalidReference indicates that the reference is malformed.
+var ErrInvalidReference = errors.New("invalid oci reference")
+
+// Reference is a reference to an OCI artifact.
+type Reference struct {
+	// Host is the host of the registry, it is empty for a local layout.
+	Host string
+	// Repository is the repository in the registry, or the path of the local layout.
+	Repository string
+	// Tag is the tag of the artifact, it is empty if the digest is set.
+	Tag string
+	// Digest is the digest of the artifact, such as "sha256:<hex>".
+	Digest string
+}
+
+// IsLayout checks whether the reference is a local OCI image layout.
+func (r Reference) IsLayout() bool {
+	return r.Host == ""
+}
+
+// Ref returns the digest if any, otherwise the tag.
+func (r Reference) Ref() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+// String satisfies fmt.Stringer.
+func (r Reference) String() string {
+	var sb strings.Builder
+
+	if r.IsLayout() {
+		sb.WriteString(SchemeLayout)
+	} else {
+		sb.WriteString(SchemeRegistry)
+		sb.WriteString(r.Host)
+		sb.WriteString("/")
+	}
+
+	sb.WriteString(r.Repository)
+
+	if r.Digest != "" {
+		sb.WriteString("@" + r.Digest)
+	} else {
+		sb.WriteString(":" + r.Tag)
+	}
+
+	return sb.String()
+}
+
+// ParseReference parses a reference, such as "oci://host/repo:tag", "oci://host/repo@sha256:<hex>" or
+// "oci-layout:///path/to/layout:tag". The tag is "latest" if there is neither a tag nor a digest.
+func ParseReference(s string) (Reference, error) {
+	var r Reference
+
+	switch {
+	case strings.HasPrefix(s, SchemeRegistry):
+		rest := strings.TrimPrefix(s, SchemeRegistry)
+
+		pos := strings.Index(rest, "/")
+		if pos <= 0 {
+			return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, s)
+		}
+
+		r.Host, r.Repository = rest[:pos], rest[pos+1:]
+
+	case strings.HasPrefix(s, SchemeLayout):
+		r.Repository = strings.TrimPrefix(s, SchemeLayout)
+
+	default:
+		return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, s)
+	}
+
+	if pos := strings.LastIndex(r.Repository, "@"); pos >= 0 {
+		r.Repository, r.Digest = r.Repository[:pos], r.Repository[pos+1:]
+
+		if !strings.HasPrefix(r.Digest, "sha256:") {
+			return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, s)
+		}
+	} else if pos := strings.LastIndex(r.Repository, ":"); pos >= 0 && !strings.Contains(r.Repository[pos:], "/") {
+		r.Repository, r.Tag = r.Repository[:pos], r.Repository[pos+1:]
+	}
+
+	if r.Digest == "" && r.Tag == "" {
+		r.Tag = defaultTag
+	}
+
+	if r.Repository == "" {
+		return Reference{}, fmt.Errorf("%w: %q", ErrInvalidReference, s)
+	}
+
+	return r, nil
+}
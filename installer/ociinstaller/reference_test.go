@@ -0,0 +1,93 @@
+package ociinstaller_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nhatthm/plugin-registry/installer/ociinstaller"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	const digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	testCases := []struct {
+		scenario       string
+		reference      string
+		expectedResult ociinstaller.Reference
+		expectedString string
+		expectedError  string
+	}{
+		{
+			scenario:       "registry with tag",
+			reference:      "oci://example.org/plugins/my-plugin:1.0.0",
+			expectedResult: ociinstaller.Reference{Host: "example.org", Repository: "plugins/my-plugin", Tag: "1.0.0"},
+			expectedString: "oci://example.org/plugins/my-plugin:1.0.0",
+		},
+		{
+			scenario:       "registry with port and without tag",
+			reference:      "oci://localhost:5000/my-plugin",
+			expectedResult: ociinstaller.Reference{Host: "localhost:5000", Repository: "my-plugin", Tag: "latest"},
+			expectedString: "oci://localhost:5000/my-plugin:latest",
+		},
+		{
+			scenario:       "registry with digest",
+			reference:      "oci://example.org/my-plugin@" + digest,
+			expectedResult: ociinstaller.Reference{Host: "example.org", Repository: "my-plugin", Digest: digest},
+			expectedString: "oci://example.org/my-plugin@" + digest,
+		},
+		{
+			scenario:       "layout with tag",
+			reference:      "oci-layout:///tmp/layout:1.0.0",
+			expectedResult: ociinstaller.Reference{Repository: "/tmp/layout", Tag: "1.0.0"},
+			expectedString: "oci-layout:///tmp/layout:1.0.0",
+		},
+		{
+			scenario:       "layout without tag",
+			reference:      "oci-layout://./my:layout/dir",
+			expectedResult: ociinstaller.Reference{Repository: "./my:layout/dir", Tag: "latest"},
+			expectedString: "oci-layout://./my:layout/dir:latest",
+		},
+		{
+			scenario:      "no repository",
+			reference:     "oci://example.org",
+			expectedError: `invalid oci reference: "oci://example.org"`,
+		},
+		{
+			scenario:      "no host",
+			reference:     "oci:///my-plugin:1.0.0",
+			expectedError: `invalid oci reference: "oci:///my-plugin:1.0.0"`,
+		},
+		{
+			scenario:      "unsupported digest",
+			reference:     "oci://example.org/my-plugin@md5:abc",
+			expectedError: `invalid oci reference: "oci://example.org/my-plugin@md5:abc"`,
+		},
+		{
+			scenario:      "unsupported scheme",
+			reference:     "https://example.org/my-plugin",
+			expectedError: `invalid oci reference: "https://example.org/my-plugin"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := ociinstaller.ParseReference(tc.reference)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, ref)
+			assert.Equal(t, tc.expectedString, ref.String())
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package ociinstaller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+)
+
+const (
+	// MediaTypeImageIndex is the media type of an OCI image index.
+	MediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// MediaTypeImageManifest is the media type of an OCI image manifest.
+	MediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// MediaTypeDockerManifestList is the media type of a docker manifest list, it is handled as an image index.
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// MediaTypeDockerManifest is the media type of a docker manifest, it is handled as an image manifest.
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationRefName is the annotation of the tag of a manifest in a local layout.
+	AnnotationRefName = "org.opencontainers.image.ref.name"
+	// AnnotationTitle is the annotation of the file name of a layer.
+	AnnotationTitle = "org.opencontainers.image.title"
+
+	layoutIndexFile = "index.json"
+
+	maxManifestSize = 4 << 20
+)
+
+var (
+	// ErrUnexpectedStatus indicates that the registry did not respond with 200 OK.
+	ErrUnexpectedStatus = errors.New("unexpected status")
+	// ErrManifestNotFound indicates that the tag is not in the local layout.
+	ErrManifestNotFound = errors.New("manifest not found")
+	// ErrManifestTooLarge indicates that the manifest exceeds the size limit.
+	ErrManifestTooLarge = errors.New("manifest is too large")
+	// ErrInvalidDigest indicates that the digest is malformed or not supported.
+	ErrInvalidDigest = errors.New("invalid digest")
+)
+
+var digestPattern = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+
+var manifestMediaTypes = []string{
+	MediaTypeImageIndex,
+	MediaTypeImageManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeDockerManifest,
+}
+
+// Descriptor describes a content in a registry or in a local layout.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Platform is the platform of a manifest in an image index.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+}
+
+// Manifest is either an image index or an image manifest.
+type Manifest struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Manifests []Descriptor `json:"manifests,omitempty"`
+	Layers    []Descriptor `json:"layers,omitempty"`
+}
+
+// IsIndex checks whether the manifest is an image index.
+func (m Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeImageIndex || m.MediaType == MediaTypeDockerManifestList ||
+		(m.MediaType == "" && len(m.Manifests) > 0)
+}
+
+// store reads the manifests and the blobs from a registry or from a local layout.
+type store interface {
+	// manifest returns the raw manifest, its media type and its digest, the ref is either a tag or a digest. The
+	// media type and the digest are empty if they are unknown.
+	manifest(ctx context.Context, ref string) ([]byte, string, string, error)
+	// blob opens a blob.
+	blob(ctx context.Context, digest string) (io.ReadCloser, error)
+}
+
+// registryStore reads from a registry with the OCI distribution api.
+type registryStore struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (s *registryStore) manifest(ctx context.Context, ref string) ([]byte, string, string, error) {
+	resp, err := s.get(ctx, "/manifests/"+ref, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	data, err := readManifest(resp.Body)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if isDigest(ref) {
+		digest = ref
+	}
+
+	return data, strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0]), digest, nil
+}
+
+func (s *registryStore) blob(ctx context.Context, digest string) (io.ReadCloser, error) {
+	resp, err := s.get(ctx, "/blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (s *registryStore) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	u := s.baseURL + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not fetch from registry", "url", u)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close() //nolint: errcheck
+
+		return nil, ctxd.WrapError(ctx, fmt.Errorf("%w: %d %s", ErrUnexpectedStatus, resp.StatusCode, http.StatusText(resp.StatusCode)),
+			"could not fetch from registry", "url", u)
+	}
+
+	return resp, nil
+}
+
+// layoutStore reads from a local OCI image layout.
+type layoutStore struct {
+	fs   afero.Fs
+	path string
+}
+
+func (s *layoutStore) manifest(ctx context.Context, ref string) ([]byte, string, string, error) {
+	var mediaType string
+
+	if !isDigest(ref) {
+		d, err := s.resolveTag(ctx, ref)
+		if err != nil {
+			return nil, "", "", err
+		}
+
+		ref, mediaType = d.Digest, d.MediaType
+	}
+
+	f, err := s.blob(ctx, ref)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	data, err := readManifest(f)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return data, mediaType, ref, nil
+}
+
+func (s *layoutStore) resolveTag(ctx context.Context, tag string) (Descriptor, error) {
+	data, err := afero.ReadFile(s.fs, filepath.Join(s.path, layoutIndexFile))
+	if err != nil {
+		return Descriptor{}, ctxd.WrapError(ctx, err, "could not read oci layout", "path", s.path)
+	}
+
+	var idx Manifest
+
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return Descriptor{}, ctxd.WrapError(ctx, err, "could not read oci layout", "path", s.path)
+	}
+
+	for _, d := range idx.Manifests {
+		if d.Annotations[AnnotationRefName] == tag {
+			return d, nil
+		}
+	}
+
+	return Descriptor{}, ctxd.WrapError(ctx, ErrManifestNotFound, "could not resolve tag", "path", s.path, "tag", tag)
+}
+
+func (s *layoutStore) blob(ctx context.Context, digest string) (io.ReadCloser, error) {
+	if err := validateDigest(digest); err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not open blob", "path", s.path)
+	}
+
+	parts := strings.SplitN(digest, ":", 2)
+
+	f, err := s.fs.Open(filepath.Join(s.path, "blobs", parts[0], parts[1]))
+	if err != nil {
+		return nil, ctxd.WrapError(ctx, err, "could not open blob", "path", s.path, "digest", digest)
+	}
+
+	return f, nil
+}
+
+func readManifest(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if _, err := buf.ReadFrom(io.LimitReader(r, maxManifestSize+1)); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() > maxManifestSize {
+		return nil, ErrManifestTooLarge
+	}
+
+	return buf.Bytes(), nil
+}
+
+func isDigest(ref string) bool {
+	return strings.Contains(ref, ":")
+}
+
+// validateDigest checks whether the digest is a sha256 or sha512 digest, such as "sha256:<hex>". Only these are
+// supported, and only a valid digest is safe to use as a path in a local layout.
+func validateDigest(digest string) error {
+	if !digestPattern.MatchString(digest) {
+		return fmt.Errorf("%w: %q", ErrInvalidDigest, digest)
+	}
+
+	return nil
+}
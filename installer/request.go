@@ -0,0 +1,40 @@
+package installer
+
+import (
+	"context"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+type requestKey struct{}
+
+// Request describes the plugin to install.
+type Request struct {
+	// Source is where to install the plugin from.
+	Source string
+	// Name is the expected name of the plugin. It is optional.
+	Name string
+	// Version is the constraint that the version of the plugin must satisfy. It is optional.
+	Version plugin.Constraint
+	// Checksum is the expected checksum of the runtime artifact, such as "sha256:<hex>". It is optional.
+	Checksum string
+	// SourceChecksum is the expected checksum of the file downloaded from the source, such as an archive. It is
+	// verified by the installers that download a file. It is optional.
+	SourceChecksum string
+}
+
+// WithRequest returns the context with the install request.
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// RequestFromContext returns the install request from context. If there is no request, the result only contains the
+// given source.
+func RequestFromContext(ctx context.Context, src string) Request {
+	r, ok := ctx.Value(requestKey{}).(Request)
+	if !ok || r.Source != src {
+		return Request{Source: src}
+	}
+
+	return r
+}
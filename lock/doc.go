@@ -0,0 +1,2 @@
+// Package lock provides an advisory file lock for synchronizing processes.
+package lock
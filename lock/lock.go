@@ -0,0 +1,240 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+)
+
+const (
+	defaultRetryInterval = 50 * time.Millisecond
+	defaultStaleAge      = 5 * time.Second
+)
+
+var (
+	// ErrLocked indicates that the lock is held by another process.
+	ErrLocked = errors.New("lock is held by another process")
+
+	errOSLockNotSupported = errors.New("os lock is not supported")
+)
+
+// FileLock is an advisory lock backed by a file.
+//
+// On the os file system, the file is locked by the os (flock or LockFileEx), so the lock is released when the process
+// exits, even if it crashes. On the other file systems, the lock is held as long as the file exists, and the file
+// records the pid of the owner. A lock file whose owner is not running anymore, or that has no pid and is older than
+// the stale age, is removed.
+type FileLock struct {
+	fs   afero.Fs
+	path string
+
+	retryInterval time.Duration
+	staleAge      time.Duration
+
+	// file is the locked file when the lock is held by the os.
+	file *os.File
+}
+
+// WithRetryInterval sets the interval between two attempts to acquire the lock.
+func (l *FileLock) WithRetryInterval(d time.Duration) *FileLock {
+	l.retryInterval = d
+
+	return l
+}
+
+// WithStaleAge sets the age after which a lock file without a pid is considered as left by a crashed process. It is
+// not used when the lock is held by the os.
+func (l *FileLock) WithStaleAge(d time.Duration) *FileLock {
+	l.staleAge = d
+
+	return l
+}
+
+// Lock acquires the lock. It waits until the lock is released by the other process or the context is done.
+func (l *FileLock) Lock(ctx context.Context) error {
+	for {
+		ok, err := l.tryLock()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctxd.WrapError(ctx, ErrLocked, "could not acquire lock", "path", l.path)
+
+		case <-time.After(l.retryInterval):
+		}
+	}
+}
+
+func (l *FileLock) tryLock() (bool, error) {
+	if _, ok := l.fs.(*afero.OsFs); ok {
+		ok, err := l.tryLockOS()
+		if !errors.Is(err, errOSLockNotSupported) {
+			return ok, err
+		}
+	}
+
+	ok, err := l.tryLockFile()
+	if ok || err != nil {
+		return ok, err
+	}
+
+	if l.removeStale() {
+		return l.tryLockFile()
+	}
+
+	return false, nil
+}
+
+// tryLockOS locks the file with the os. The file is removed when the lock is released, so the lock is retried if the
+// file was removed, or replaced, before it was locked.
+func (l *FileLock) tryLockOS() (bool, error) {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, os.FileMode(0o644)) //nolint: nosnakecase
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := lockFile(f)
+	if err != nil || !ok {
+		_ = f.Close() //nolint: errcheck
+
+		return false, err
+	}
+
+	if !isSameFile(f, l.path) {
+		_ = f.Close() //nolint: errcheck
+
+		return false, nil
+	}
+
+	if err := writePid(f); err != nil {
+		_ = f.Close() //nolint: errcheck
+
+		return false, err
+	}
+
+	l.file = f
+
+	return true, nil
+}
+
+// tryLockFile creates the lock file if it does not exist.
+func (l *FileLock) tryLockFile() (bool, error) {
+	f, err := l.fs.OpenFile(l.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o644)) //nolint: nosnakecase
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = l.fs.Remove(l.path) //nolint: errcheck
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// removeStale removes the lock file if its owner is not running anymore. A lock file without a pid is stale once it
+// is older than the stale age, because the pid is written right after the file is created.
+func (l *FileLock) removeStale() bool {
+	data, err := afero.ReadFile(l.fs, l.path)
+	if err != nil {
+		return false
+	}
+
+	if pid, err := strconv.Atoi(string(bytes.TrimSpace(data))); err == nil && pid > 0 {
+		if isProcessRunning(pid) {
+			return false
+		}
+	} else {
+		fi, err := l.fs.Stat(l.path)
+		if err != nil || time.Since(fi.ModTime()) < l.staleAge {
+			return false
+		}
+	}
+
+	// Make sure that the lock file has not been taken over in the meantime.
+	if current, err := afero.ReadFile(l.fs, l.path); err != nil || !bytes.Equal(current, data) {
+		return false
+	}
+
+	return l.fs.Remove(l.path) == nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	if l.file == nil {
+		return l.fs.Remove(l.path)
+	}
+
+	var err error
+
+	// The file is removed while it is still locked, the other processes retry when they lock a removed file.
+	if removeOnUnlock {
+		err = os.Remove(l.path)
+	}
+
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	l.file = nil
+
+	return err
+}
+
+func writePid(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	return err
+}
+
+func isSameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	pathFi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fi, pathFi)
+}
+
+// New initiates a new FileLock.
+func New(fs afero.Fs, path string) *FileLock {
+	return &FileLock{
+		fs:            fs,
+		path:          path,
+		retryInterval: defaultRetryInterval,
+		staleAge:      defaultStaleAge,
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package lock
+
+import "os"
+
+// removeOnUnlock tells whether the lock file is removed when the lock is released.
+const removeOnUnlock = true
+
+// lockFile is not supported, the lock falls back to the existence of the file.
+func lockFile(*os.File) (bool, error) {
+	return false, errOSLockNotSupported
+}
+
+// isProcessRunning can not tell whether the process is running, so it is considered as running.
+func isProcessRunning(int) bool {
+	return true
+}
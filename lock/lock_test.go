@@ -0,0 +1,193 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.nhat.io/aferomock"
+
+	"github.com/nhatthm/plugin-registry/lock"
+)
+
+func TestFileLock_Lock_Error(t *testing.T) {
+	t.Parallel()
+
+	fs := aferomock.MockFs(func(fs *aferomock.Fs) {
+		//nolint: nosnakecase
+		fs.On("OpenFile", ".lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o644)).
+			Return(nil, errors.New("open error"))
+	})(t)
+
+	err := lock.New(fs, ".lock").Lock(context.Background())
+
+	require.EqualError(t, err, "open error")
+}
+
+func TestFileLock_Lock_Timeout(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	l1 := lock.New(fs, ".lock")
+	l2 := lock.New(fs, ".lock").WithRetryInterval(time.Millisecond)
+
+	err := l1.Lock(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = l2.Lock(ctx)
+
+	require.ErrorIs(t, err, lock.ErrLocked)
+	assert.EqualError(t, err, "could not acquire lock: lock is held by another process")
+}
+
+func TestFileLock_Lock_WaitForRelease(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewOsFs()
+	path := t.TempDir() + "/.lock"
+
+	l1 := lock.New(fs, path)
+	l2 := lock.New(fs, path).WithRetryInterval(time.Millisecond)
+
+	err := l1.Lock(context.Background())
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+
+		_ = l1.Unlock() //nolint: errcheck
+	}()
+
+	err = l2.Lock(context.Background())
+	require.NoError(t, err)
+
+	err = l2.Unlock()
+	require.NoError(t, err)
+
+	exists, err := afero.Exists(fs, path)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestFileLock_Lock_OsTimeout(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewOsFs()
+	path := t.TempDir() + "/.lock"
+
+	l1 := lock.New(fs, path)
+	l2 := lock.New(fs, path).WithRetryInterval(time.Millisecond)
+
+	err := l1.Lock(context.Background())
+	require.NoError(t, err)
+
+	defer l1.Unlock() //nolint: errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = l2.Lock(ctx)
+
+	require.ErrorIs(t, err, lock.ErrLocked)
+}
+
+func TestFileLock_Lock_StaleOsLock(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewOsFs()
+	path := t.TempDir() + "/.lock"
+
+	// The lock file of a crashed process is not locked anymore.
+	require.NoError(t, afero.WriteFile(fs, path, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0o644))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	l := lock.New(fs, path)
+
+	require.NoError(t, l.Lock(ctx))
+	require.NoError(t, l.Unlock())
+}
+
+func TestFileLock_Lock_StaleLockFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		content  string
+		modTime  time.Time
+		expected error
+	}{
+		{
+			scenario: "owner is not running",
+			content:  fmt.Sprintf("%d\n", exitedPid(t)),
+			modTime:  time.Now(),
+		},
+		{
+			scenario: "no pid and old",
+			modTime:  time.Now().Add(-time.Minute),
+		},
+		{
+			scenario: "no pid and recent",
+			modTime:  time.Now(),
+			expected: lock.ErrLocked,
+		},
+		{
+			scenario: "owner is running",
+			content:  fmt.Sprintf("%d\n", os.Getpid()),
+			modTime:  time.Now().Add(-time.Minute),
+			expected: lock.ErrLocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			require.NoError(t, afero.WriteFile(fs, ".lock", []byte(tc.content), 0o644))
+			require.NoError(t, fs.Chtimes(".lock", tc.modTime, tc.modTime))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := lock.New(fs, ".lock").WithRetryInterval(time.Millisecond).Lock(ctx)
+
+			if tc.expected != nil {
+				require.ErrorIs(t, err, tc.expected)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			data, err := afero.ReadFile(fs, ".lock")
+			require.NoError(t, err)
+
+			assert.Equal(t, fmt.Sprintf("%d\n", os.Getpid()), string(data))
+		})
+	}
+}
+
+// exitedPid returns the pid of a process that has exited.
+func exitedPid(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+
+	return cmd.Process.Pid
+}
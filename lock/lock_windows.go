@@ -0,0 +1,52 @@
+//go:build windows
+// +build windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// removeOnUnlock tells whether the lock file is removed when the lock is released. An open file can not be removed on
+// windows, so the lock file is kept.
+const removeOnUnlock = false
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errorLockViolation syscall.Errno = 33
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+// lockFile locks the file with LockFileEx without waiting.
+func lockFile(f *os.File) (bool, error) {
+	var ol syscall.Overlapped
+
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0,
+		uintptr(unsafe.Pointer(&ol))) //nolint: gosec
+	if r != 0 {
+		return true, nil
+	}
+
+	if errors.Is(err, errorLockViolation) || errors.Is(err, syscall.ERROR_IO_PENDING) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+func isProcessRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	_ = p.Release() //nolint: errcheck
+
+	return true
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/lockfile"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// Lockfile returns the lockfile of the installed plugins, with the checksum of their artifact for the current platform.
+func (r *FsRegistry) Lockfile() (lockfile.Lockfile, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return lockfile.Lockfile{}, err
+	}
+
+	l := lockfile.Lockfile{Plugins: make(map[string]lockfile.Entry, len(cfg.Plugins))}
+
+	for name, p := range cfg.Plugins {
+		p := p
+
+		e := lockfile.Entry{
+			Source:  p.Source,
+			Version: p.Version,
+		}
+
+		checksum, err := r.artifactChecksum(&p)
+		if err != nil {
+			return lockfile.Lockfile{}, err
+		}
+
+		if checksum != "" {
+			e.Artifacts = map[string]string{runtimeArtifactIdentifier(&p).String(): checksum}
+		}
+
+		l.Plugins[name] = e
+	}
+
+	return l, nil
+}
+
+// WriteLockfile writes the lockfile of the installed plugins. The checksums of the other platforms in the existing
+// lockfile are kept.
+func (r *FsRegistry) WriteLockfile(path string) error {
+	l, err := r.Lockfile()
+	if err != nil {
+		return err
+	}
+
+	if old, err := lockfile.Load(r.fs, path); err == nil {
+		l = l.Merge(old)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return lockfile.Write(r.fs, path, l)
+}
+
+// Sync installs, reinstalls and uninstalls plugins until the registry matches the lockfile.
+func (r *FsRegistry) Sync(ctx context.Context, l lockfile.Lockfile) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	unlocked := make([]string, 0, len(cfg.Plugins))
+
+	for _, name := range sortedNames(cfg.Plugins) {
+		if _, ok := l.Plugins[name]; !ok {
+			unlocked = append(unlocked, name)
+		}
+	}
+
+	for _, name := range removalOrder(cfg.Plugins, unlocked) {
+		if err := r.Uninstall(name); err != nil {
+			return ctxd.WrapError(ctx, err, "could not uninstall plugin", "name", name)
+		}
+	}
+
+	names := make([]string, 0, len(l.Plugins))
+
+	for name := range l.Plugins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := l.Plugins[name]
+
+		if p, ok := cfg.Plugins[name]; ok {
+			matched, err := r.matchLock(&p, e)
+			if err != nil {
+				return err
+			}
+
+			if matched {
+				continue
+			}
+		}
+
+		req, err := lockRequest(name, e)
+		if err != nil {
+			return err
+		}
+
+		if err := r.InstallRequest(ctx, req); err != nil {
+			return ctxd.WrapError(ctx, err, "could not install plugin", "name", name)
+		}
+	}
+
+	return nil
+}
+
+// matchLock checks whether the installed plugin is the locked one.
+func (r *FsRegistry) matchLock(p *plugin.Plugin, e lockfile.Entry) (bool, error) {
+	if p.Source != e.Source || p.Version != e.Version {
+		return false, nil
+	}
+
+	expected := e.RuntimeChecksum()
+	if expected == "" {
+		return true, nil
+	}
+
+	pluginDir := filepath.Join(r.path, p.Name)
+
+	if err := verifyArtifact(r.fs, pluginDir, p, expected); err != nil {
+		return false, nil //nolint: nilerr
+	}
+
+	return true, nil
+}
+
+// artifactChecksum returns the checksum of the runtime artifact. The checksum in the metadata is used if any, otherwise
+// it is computed from the installed file.
+func (r *FsRegistry) artifactChecksum(p *plugin.Plugin) (string, error) {
+	a := p.ResolveArtifact(p.RuntimeArtifact())
+
+	if a.Checksum != "" {
+		return a.Checksum, nil
+	}
+
+	path, err := p.RuntimeArtifactPath(filepath.Join(r.path, p.Name))
+	if err != nil {
+		return "", err
+	}
+
+	if exists, err := afero.Exists(r.fs, path); err != nil || !exists {
+		return "", err
+	}
+
+	return plugin.ComputeChecksum(r.fs, path, plugin.ChecksumSHA256)
+}
+
+func lockRequest(name string, e lockfile.Entry) (installer.Request, error) {
+	req := installer.Request{
+		Source:   e.Source,
+		Name:     name,
+		Checksum: e.RuntimeChecksum(),
+	}
+
+	if e.Version != "" {
+		c, err := plugin.ParseConstraint("=" + e.Version)
+		if err != nil {
+			return installer.Request{}, err
+		}
+
+		req.Version = c
+	}
+
+	return req, nil
+}
+
+// runtimeArtifactIdentifier returns the identifier of the artifact for the current platform.
+func runtimeArtifactIdentifier(p *plugin.Plugin) plugin.ArtifactIdentifier {
+	if id := plugin.RuntimeArtifactIdentifierWithoutArch(); p.Artifacts.Has(id) && !p.Artifacts.Has(plugin.RuntimeArtifactIdentifier()) {
+		return id
+	}
+
+	return plugin.RuntimeArtifactIdentifier()
+}
+
+func sortedNames(plugins plugin.Plugins) []string {
+	names := make([]string, 0, len(plugins))
+
+	for name := range plugins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,2 @@
+// Package lockfile provides functionalities for reading and writing the lockfile of a registry.
+package lockfile
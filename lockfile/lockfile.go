@@ -0,0 +1,110 @@
+package lockfile
+
+import (
+	"context"
+	"os"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// DefaultFile is the default name of the lockfile.
+const DefaultFile = "plugins.lock"
+
+// Lockfile captures the exact plugins of a registry.
+type Lockfile struct {
+	Plugins map[string]Entry `yaml:"plugins"`
+}
+
+// Merge keeps the artifact checksums of the other lockfile for the plugins that have the same source and version.
+// It is useful to keep the checksums of the other platforms.
+func (l Lockfile) Merge(other Lockfile) Lockfile {
+	result := Lockfile{Plugins: make(map[string]Entry, len(l.Plugins))}
+
+	for name, e := range l.Plugins {
+		o, ok := other.Plugins[name]
+		if ok && o.Source == e.Source && o.Version == e.Version {
+			artifacts := make(map[string]string, len(o.Artifacts)+len(e.Artifacts))
+
+			for id, checksum := range o.Artifacts {
+				artifacts[id] = checksum
+			}
+
+			for id, checksum := range e.Artifacts {
+				artifacts[id] = checksum
+			}
+
+			e.Artifacts = artifacts
+		}
+
+		result.Plugins[name] = e
+	}
+
+	return result
+}
+
+// Entry is a locked plugin.
+type Entry struct {
+	Source  string `yaml:"source"`
+	Version string `yaml:"version"`
+	// Artifacts is a map of artifact identifier and checksum.
+	Artifacts map[string]string `yaml:"artifacts,omitempty"`
+}
+
+// RuntimeChecksum returns the checksum of the artifact for the current platform, it is empty if the artifact is not
+// locked.
+func (e Entry) RuntimeChecksum() string {
+	if checksum, ok := e.Artifacts[plugin.RuntimeArtifactIdentifier().String()]; ok {
+		return checksum
+	}
+
+	return e.Artifacts[plugin.RuntimeArtifactIdentifierWithoutArch().String()]
+}
+
+// Load loads a lockfile.
+func Load(fs afero.Fs, path string) (Lockfile, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return Lockfile{}, err
+	}
+	defer f.Close() //nolint: errcheck
+
+	var l Lockfile
+
+	if err := yaml.NewDecoder(f).Decode(&l); err != nil {
+		return Lockfile{}, ctxd.WrapError(context.Background(), err, "could not read lockfile", "path", path)
+	}
+
+	return l, nil
+}
+
+// Write writes a lockfile to a temporary file then renames it, so the lockfile is never left truncated.
+func Write(fs afero.Fs, path string, l Lockfile) error {
+	if l.Plugins == nil {
+		l.Plugins = map[string]Entry{}
+	}
+
+	tmpFile := path + ".tmp"
+
+	f, err := fs.OpenFile(tmpFile, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(0o644)) //nolint: nosnakecase
+	if err != nil {
+		return err
+	}
+
+	err = yaml.NewEncoder(f).Encode(l)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = fs.Remove(tmpFile) //nolint: errcheck
+
+		return err
+	}
+
+	return fs.Rename(tmpFile, path)
+}
@@ -0,0 +1,132 @@
+package lockfile_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/lockfile"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	_, err := lockfile.Load(fs, "plugins.lock")
+	require.EqualError(t, err, "open plugins.lock: file does not exist")
+
+	err = afero.WriteFile(fs, "plugins.lock", []byte("plugins: []"), 0o644)
+	require.NoError(t, err)
+
+	_, err = lockfile.Load(fs, "plugins.lock")
+	require.EqualError(t, err, "could not read lockfile: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]lockfile.Entry")
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	l := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"my-plugin": {
+			Source:    "github.com/example/my-plugin",
+			Version:   "v1.2.0",
+			Artifacts: map[string]string{"linux/amd64": "sha256:abc"},
+		},
+		"another-plugin": {
+			Source:  "github.com/example/another-plugin",
+			Version: "v2.0.0",
+		},
+	}}
+
+	err := lockfile.Write(fs, "plugins.lock", l)
+	require.NoError(t, err)
+
+	expected := `plugins:
+    another-plugin:
+        source: github.com/example/another-plugin
+        version: v2.0.0
+    my-plugin:
+        source: github.com/example/my-plugin
+        version: v1.2.0
+        artifacts:
+            linux/amd64: sha256:abc
+`
+
+	actual, err := afero.ReadFile(fs, "plugins.lock")
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(actual))
+
+	loaded, err := lockfile.Load(fs, "plugins.lock")
+	require.NoError(t, err)
+
+	assert.Equal(t, l, loaded)
+
+	exists, err := afero.Exists(fs, "plugins.lock.tmp")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestWrite_Empty(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	err := lockfile.Write(fs, "plugins.lock", lockfile.Lockfile{})
+	require.NoError(t, err)
+
+	actual, err := afero.ReadFile(fs, "plugins.lock")
+	require.NoError(t, err)
+
+	assert.Equal(t, "plugins: {}\n", string(actual))
+}
+
+func TestLockfile_Merge(t *testing.T) {
+	t.Parallel()
+
+	current := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"same-version": {Source: "src", Version: "v1.0.0", Artifacts: map[string]string{"linux/amd64": "sha256:new"}},
+		"new-version":  {Source: "src", Version: "v2.0.0", Artifacts: map[string]string{"linux/amd64": "sha256:v2"}},
+	}}
+
+	old := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"same-version": {Source: "src", Version: "v1.0.0", Artifacts: map[string]string{
+			"linux/amd64":  "sha256:old",
+			"darwin/arm64": "sha256:darwin",
+		}},
+		"new-version": {Source: "src", Version: "v1.0.0", Artifacts: map[string]string{"darwin/arm64": "sha256:v1"}},
+		"removed":     {Source: "src", Version: "v1.0.0"},
+	}}
+
+	expected := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"same-version": {Source: "src", Version: "v1.0.0", Artifacts: map[string]string{
+			"linux/amd64":  "sha256:new",
+			"darwin/arm64": "sha256:darwin",
+		}},
+		"new-version": {Source: "src", Version: "v2.0.0", Artifacts: map[string]string{"linux/amd64": "sha256:v2"}},
+	}}
+
+	assert.Equal(t, expected, current.Merge(old))
+}
+
+func TestEntry_RuntimeChecksum(t *testing.T) {
+	t.Parallel()
+
+	withArch := lockfile.Entry{Artifacts: map[string]string{
+		plugin.RuntimeArtifactIdentifier().String():            "sha256:arch",
+		plugin.RuntimeArtifactIdentifierWithoutArch().String(): "sha256:os",
+	}}
+
+	withoutArch := lockfile.Entry{Artifacts: map[string]string{
+		plugin.RuntimeArtifactIdentifierWithoutArch().String(): "sha256:os",
+	}}
+
+	assert.Equal(t, "sha256:arch", withArch.RuntimeChecksum())
+	assert.Equal(t, "sha256:os", withoutArch.RuntimeChecksum())
+	assert.Empty(t, lockfile.Entry{}.RuntimeChecksum())
+}
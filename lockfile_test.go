@@ -0,0 +1,194 @@
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/lockfile"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// artifactPlugin returns a plugin with a binary artifact, see artifactFile().
+func artifactPlugin(name, version string) *plugin.Plugin {
+	return &plugin.Plugin{
+		Name:    name,
+		Version: version,
+		Enabled: true,
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}"},
+		},
+	}
+}
+
+// artifactFile returns the binary artifact of a plugin returned by artifactPlugin().
+func artifactFile(name, content string) installerMock.File {
+	return installerMock.File{Path: name, Content: content, Mode: 0o755}
+}
+
+func TestRegistry_Lockfile(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source, artifactPlugin("my-plugin", "v1.2.0"), artifactFile("my-plugin", "hello"))
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	err = r.Install(context.Background(), source)
+	require.NoError(t, err)
+
+	// Keep the checksums of the other platforms.
+	err = lockfile.Write(fs, "/tmp/plugins.lock", lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"my-plugin": {Source: source, Version: "v1.2.0", Artifacts: map[string]string{"other/arch": "sha256:other"}},
+		"removed":   {Source: source, Version: "v1.0.0"},
+	}})
+	require.NoError(t, err)
+
+	err = r.WriteLockfile("/tmp/plugins.lock")
+	require.NoError(t, err)
+
+	actual, err := lockfile.Load(fs, "/tmp/plugins.lock")
+	require.NoError(t, err)
+
+	expected := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"my-plugin": {
+			Source:  source,
+			Version: "v1.2.0",
+			Artifacts: map[string]string{
+				plugin.RuntimeArtifactIdentifier().String(): "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+				"other/arch": "sha256:other",
+			},
+		},
+	}}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestRegistry_Lockfile_ArtifactOutsidePluginDir(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/tmp/secret", []byte("secret"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/tmp/plugins/config.yaml", []byte(fmt.Sprintf(`plugins:
+    my-plugin:
+        name: my-plugin
+        version: v1.0.0
+        enabled: true
+        artifacts:
+            %s:
+                file: ../../secret
+`, plugin.RuntimeArtifactIdentifier())), 0o644))
+
+	r, err := registry.NewRegistry("/tmp/plugins", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	_, err = r.Lockfile()
+	require.ErrorIs(t, err, plugin.ErrInvalidArtifactPath)
+}
+
+func TestRegistry_Sync(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/a", artifactPlugin("plugin-a", "v1.0.0"), artifactFile("plugin-a", "plugin a"))
+	installerMock.RegisterPlugin(source+"/b", artifactPlugin("plugin-b", "v2.0.0"), artifactFile("plugin-b", "plugin b"))
+	installerMock.RegisterPlugin(source+"/c", artifactPlugin("plugin-c", "v3.0.0"), artifactFile("plugin-c", "plugin c"))
+	installerMock.RegisterPlugin(source+"/old-a", artifactPlugin("plugin-a", "v0.9.0"), artifactFile("plugin-a", "old plugin a"))
+
+	// The reference machine.
+	reference, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, reference.Install(context.Background(), source+"/a"))
+	require.NoError(t, reference.Install(context.Background(), source+"/b"))
+
+	l, err := reference.Lockfile()
+	require.NoError(t, err)
+
+	// Another machine has an old version of plugin-a, and an extra plugin-c.
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source+"/old-a"))
+	require.NoError(t, r.Install(context.Background(), source+"/c"))
+	require.NoError(t, r.Disable("plugin-a"))
+
+	err = r.Sync(context.Background(), l)
+	require.NoError(t, err)
+
+	actual, err := r.Lockfile()
+	require.NoError(t, err)
+
+	assert.Equal(t, l, actual)
+
+	exists, err := afero.Exists(fs, "/tmp/plugin-c")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// The state of the plugins is kept.
+	p, err := r.GetPlugin("plugin-a")
+	require.NoError(t, err)
+	assert.False(t, p.Enabled)
+
+	// Sync again does nothing.
+	err = r.Sync(context.Background(), l)
+	require.NoError(t, err)
+}
+
+func TestRegistry_Sync_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source, artifactPlugin("my-plugin", "v1.0.0"), artifactFile("my-plugin", "hello"))
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	l := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"my-plugin": {
+			Source:    source,
+			Version:   "v1.0.0",
+			Artifacts: map[string]string{plugin.RuntimeArtifactIdentifier().String(): "sha256:abc"},
+		},
+	}}
+
+	err = r.Sync(context.Background(), l)
+	require.ErrorIs(t, err, plugin.ErrIntegrity)
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestRegistry_Sync_VersionMismatch(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source, artifactPlugin("my-plugin", "v1.1.0"), artifactFile("my-plugin", "hello"))
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	l := lockfile.Lockfile{Plugins: map[string]lockfile.Entry{
+		"my-plugin": {Source: source, Version: "v1.0.0"},
+	}}
+
+	err = r.Sync(context.Background(), l)
+	require.ErrorIs(t, err, registry.ErrVersionNotSatisfied)
+}
@@ -0,0 +1,72 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// File is a file of a plugin, the path is relative to the plugin directory.
+type File struct {
+	Path    string
+	Content string
+	Mode    os.FileMode
+}
+
+// Metadata returns the metadata file of the plugin.
+func Metadata(p *plugin.Plugin) ([]byte, error) {
+	return yaml.Marshal(p)
+}
+
+// RegisterPlugin registers an installer for the source. The installer writes the metadata of the plugin and the files
+// into the plugin directory, and returns a copy of the plugin.
+func RegisterPlugin(source string, p *plugin.Plugin, files ...File) {
+	installer.Register(source, func(_ context.Context, src string) bool {
+		return src == source
+	}, func(fs afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(_ context.Context, dest, _ string) (*plugin.Plugin, error) {
+			if err := InstallPlugin(fs, filepath.Join(dest, p.Name), p, files...); err != nil {
+				return nil, err
+			}
+
+			installed := *p
+
+			return &installed, nil
+		})
+	})
+}
+
+// InstallPlugin writes the metadata of the plugin and the files into the plugin directory.
+func InstallPlugin(fs afero.Fs, pluginDir string, p *plugin.Plugin, files ...File) error {
+	metadata, err := Metadata(p)
+	if err != nil {
+		return err
+	}
+
+	files = append([]File{{Path: plugin.MetadataFile, Content: string(metadata)}}, files...)
+
+	for _, f := range files {
+		path := filepath.Join(pluginDir, f.Path)
+
+		if err := fs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0o644
+		}
+
+		if err := afero.WriteFile(fs, path, []byte(f.Content), mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
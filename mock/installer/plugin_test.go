@@ -0,0 +1,56 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestRegisterPlugin(t *testing.T) {
+	t.Parallel()
+
+	expected := &plugin.Plugin{
+		Name:     "my-plugin",
+		Version:  "v1.2.0",
+		Enabled:  true,
+		Requires: plugin.Requirements{{Name: "lib", Version: plugin.MustParseConstraint("^1")}},
+	}
+
+	RegisterPlugin(t.Name(), expected, File{Path: "bin/my-plugin", Content: "hello", Mode: 0o755})
+
+	fs := afero.NewMemMapFs()
+
+	i, err := installer.Find(fsCtx.WithFs(context.Background(), fs), t.Name())
+	require.NoError(t, err)
+
+	p, err := i.Install(context.Background(), "/tmp", t.Name())
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, p)
+	assert.NotSame(t, expected, p)
+
+	loaded, err := plugin.Load(fs, "/tmp/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, expected.Name, loaded.Name)
+	assert.Equal(t, expected.Version, loaded.Version)
+	assert.Equal(t, expected.Requires, loaded.Requires)
+
+	fi, err := fs.Stat("/tmp/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+
+	content, err := afero.ReadFile(fs, "/tmp/my-plugin/bin/my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello", string(content))
+}
@@ -6,6 +6,7 @@ import (
 
 	registry "github.com/nhatthm/plugin-registry"
 	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/installer"
 	"github.com/stretchr/testify/assert"
 	tMock "github.com/stretchr/testify/mock"
 )
@@ -40,16 +41,41 @@ func (r *Registry) Disable(name string) error {
 	return r.Called(name).Error(0)
 }
 
+// ForceDisable satisfies registry.Registry.
+func (r *Registry) ForceDisable(name string) error {
+	return r.Called(name).Error(0)
+}
+
 // Install satisfies registry.Registry.
 func (r *Registry) Install(ctx context.Context, source string) error {
 	return r.Called(ctx, source).Error(0)
 }
 
+// InstallRequest satisfies registry.Registry.
+func (r *Registry) InstallRequest(ctx context.Context, req installer.Request) error {
+	return r.Called(ctx, req).Error(0)
+}
+
 // Uninstall satisfies registry.Registry.
 func (r *Registry) Uninstall(name string) error {
 	return r.Called(name).Error(0)
 }
 
+// ForceUninstall satisfies registry.Registry.
+func (r *Registry) ForceUninstall(name string) error {
+	return r.Called(name).Error(0)
+}
+
+// Upgrade satisfies registry.Registry.
+func (r *Registry) Upgrade(ctx context.Context, name string) error {
+	return r.Called(ctx, name).Error(0)
+}
+
+// UpgradeAll satisfies registry.Registry.
+func (r *Registry) UpgradeAll(ctx context.Context) error {
+	return r.Called(ctx).Error(0)
+}
+
 // New mocks registry.Registry interface.
 func New(mocks ...func(r *Registry)) *Registry {
 	r := &Registry{}
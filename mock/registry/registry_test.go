@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/installer"
 	"github.com/nhatthm/plugin-registry/plugin"
 )
 
@@ -143,6 +144,47 @@ func TestDisable(t *testing.T) {
 	}
 }
 
+func TestForceDisable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		mockRegistry  Mocker
+		expectedError string
+	}{
+		{
+			scenario: "error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("ForceDisable", "my-plugin").
+					Return(errors.New("error"))
+			}),
+			expectedError: "error",
+		},
+		{
+			scenario: "no error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("ForceDisable", "my-plugin").
+					Return(nil)
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.mockRegistry(t).ForceDisable("my-plugin")
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func TestInstall(t *testing.T) {
 	t.Parallel()
 
@@ -184,6 +226,49 @@ func TestInstall(t *testing.T) {
 	}
 }
 
+func TestInstallRequest(t *testing.T) {
+	t.Parallel()
+
+	req := installer.Request{Source: "my-plugin"}
+
+	testCases := []struct {
+		scenario      string
+		mockRegistry  Mocker
+		expectedError string
+	}{
+		{
+			scenario: "error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("InstallRequest", context.Background(), req).
+					Return(errors.New("error"))
+			}),
+			expectedError: "error",
+		},
+		{
+			scenario: "no error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("InstallRequest", context.Background(), req).
+					Return(nil)
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.mockRegistry(t).InstallRequest(context.Background(), req)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func TestUninstall(t *testing.T) {
 	t.Parallel()
 
@@ -224,3 +309,126 @@ func TestUninstall(t *testing.T) {
 		})
 	}
 }
+
+func TestForceUninstall(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		mockRegistry  Mocker
+		expectedError string
+	}{
+		{
+			scenario: "error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("ForceUninstall", "my-plugin").
+					Return(errors.New("error"))
+			}),
+			expectedError: "error",
+		},
+		{
+			scenario: "no error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("ForceUninstall", "my-plugin").
+					Return(nil)
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.mockRegistry(t).ForceUninstall("my-plugin")
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		mockRegistry  Mocker
+		expectedError string
+	}{
+		{
+			scenario: "error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("Upgrade", context.Background(), "my-plugin").
+					Return(errors.New("error"))
+			}),
+			expectedError: "error",
+		},
+		{
+			scenario: "no error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("Upgrade", context.Background(), "my-plugin").
+					Return(nil)
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.mockRegistry(t).Upgrade(context.Background(), "my-plugin")
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestUpgradeAll(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		mockRegistry  Mocker
+		expectedError string
+	}{
+		{
+			scenario: "error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("UpgradeAll", context.Background()).
+					Return(errors.New("error"))
+			}),
+			expectedError: "error",
+		},
+		{
+			scenario: "no error",
+			mockRegistry: MockRegistry(func(r *Registry) {
+				r.On("UpgradeAll", context.Background()).
+					Return(nil)
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.mockRegistry(t).UpgradeAll(context.Background())
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bool64/ctxd"
+	"gopkg.in/yaml.v3"
+
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// ErrUnknownAction indicates that the action is not supported.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ActionType is the type of action to reconcile the registry.
+type ActionType string
+
+const (
+	// ActionRemove uninstalls a plugin.
+	ActionRemove ActionType = "remove"
+	// ActionInstall installs a new plugin.
+	ActionInstall ActionType = "install"
+	// ActionUpgrade reinstalls a plugin from another source or in another version.
+	ActionUpgrade ActionType = "upgrade"
+	// ActionEnable enables a plugin.
+	ActionEnable ActionType = "enable"
+	// ActionDisable disables a plugin.
+	ActionDisable ActionType = "disable"
+)
+
+// DesiredState describes the plugins that the registry should have. The installed plugins that are not in the desired
+// state are removed.
+type DesiredState struct {
+	Plugins []DesiredPlugin `yaml:"plugins"`
+}
+
+// DesiredPlugin describes a plugin that the registry should have.
+type DesiredPlugin struct {
+	Name    string            `yaml:"name"`
+	Source  string            `yaml:"source"`
+	Version plugin.Constraint `yaml:"version,omitempty"`
+	Enabled bool              `yaml:"enabled"`
+}
+
+// UnmarshalYAML satisfies yaml.Unmarshaler.
+func (p *DesiredPlugin) UnmarshalYAML(value *yaml.Node) error {
+	type rawDesiredPlugin DesiredPlugin
+
+	raw := rawDesiredPlugin{Enabled: true}
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	*p = DesiredPlugin(raw)
+
+	return nil
+}
+
+// Action is a step to reconcile the registry with the desired state.
+type Action struct {
+	Type    ActionType
+	Name    string
+	Source  string
+	Version plugin.Constraint
+}
+
+// String satisfies fmt.Stringer.
+func (a Action) String() string {
+	switch a.Type {
+	case ActionInstall, ActionUpgrade:
+		if a.Version.IsEmpty() {
+			return fmt.Sprintf("%s %s from %s", a.Type, a.Name, a.Source)
+		}
+
+		return fmt.Sprintf("%s %s@%s from %s", a.Type, a.Name, a.Version, a.Source)
+
+	default:
+		return fmt.Sprintf("%s %s", a.Type, a.Name)
+	}
+}
+
+// Plan is a list of actions to reconcile the registry with the desired state.
+type Plan struct {
+	Actions []Action
+}
+
+// IsEmpty checks whether the registry is already in the desired state.
+func (p Plan) IsEmpty() bool {
+	return len(p.Actions) == 0
+}
+
+// Plan computes the actions to reconcile the registry with the desired state, nothing is changed. The plugins are
+// removed first, then installed or upgraded, then enabled or disabled.
+func (r *FsRegistry) Plan(ctx context.Context, desired DesiredState) (Plan, error) {
+	cfg, err := r.Config()
+	if err != nil {
+		return Plan{}, err
+	}
+
+	wanted := make(map[string]DesiredPlugin, len(desired.Plugins))
+
+	for _, d := range desired.Plugins {
+		wanted[d.Name] = d
+	}
+
+	var (
+		removes, installs, states []Action
+		enables, disables         []string
+	)
+
+	unwanted := make([]string, 0, len(cfg.Plugins))
+
+	for _, name := range sortedNames(cfg.Plugins) {
+		if _, ok := wanted[name]; !ok {
+			unwanted = append(unwanted, name)
+		}
+	}
+
+	// Remove the plugins before the ones they require.
+	for _, name := range removalOrder(cfg.Plugins, unwanted) {
+		removes = append(removes, Action{Type: ActionRemove, Name: name})
+	}
+
+	names := make([]string, 0, len(wanted))
+
+	for name := range wanted {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		d := wanted[name]
+		p, installed := cfg.Plugins[name]
+
+		action := Action{Name: name, Source: d.Source, Version: d.Version}
+
+		switch {
+		case !installed:
+			action.Type = ActionInstall
+
+		// The plugin is reinstalled when it is wanted from another source than the one it was installed from.
+		case p.Source != d.Source || !satisfies(d.Version, p.Version):
+			action.Type = ActionUpgrade
+		}
+
+		if action.Type != "" {
+			if err := r.checkSource(ctx, installer.Request{Source: d.Source, Name: name, Version: d.Version}); err != nil {
+				return Plan{}, ctxd.WrapError(ctx, err, "could not plan plugin", "name", name, "source", d.Source)
+			}
+
+			installs = append(installs, action)
+		}
+
+		// A newly installed plugin may be enabled or disabled by its metadata.
+		switch {
+		case action.Type != ActionInstall && p.Enabled == d.Enabled:
+		case d.Enabled:
+			enables = append(enables, name)
+		default:
+			disables = append(disables, name)
+		}
+	}
+
+	// Disable the plugins before the plugins they require, and enable them after.
+	for _, name := range removalOrder(cfg.Plugins, disables) {
+		states = append(states, stateAction(name, false))
+	}
+
+	for _, name := range enableOrder(cfg.Plugins, enables) {
+		states = append(states, stateAction(name, true))
+	}
+
+	actions := make([]Action, 0, len(removes)+len(installs)+len(states))
+	actions = append(actions, removes...)
+	actions = append(actions, installs...)
+	actions = append(actions, states...)
+
+	return Plan{Actions: actions}, nil
+}
+
+// checkSource checks whether the source can be installed, the plugins of the index are resolved first.
+func (r *FsRegistry) checkSource(ctx context.Context, req installer.Request) error {
+	req, err := r.resolveIndex(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	_, err = installer.Find(fsCtx.WithFs(ctx, r.fs), req.Source)
+
+	return err
+}
+
+// Apply executes the plan.
+func (r *FsRegistry) Apply(ctx context.Context, plan Plan) error {
+	for _, a := range plan.Actions {
+		if err := r.apply(ctx, a); err != nil {
+			return ctxd.WrapError(ctx, err, "could not apply action", "action", a.String())
+		}
+	}
+
+	return nil
+}
+
+// Reconcile computes the plan to reconcile the registry with the desired state and applies it, unless it is a dry run.
+func (r *FsRegistry) Reconcile(ctx context.Context, desired DesiredState, dryRun bool) (Plan, error) {
+	plan, err := r.Plan(ctx, desired)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	return plan, r.Apply(ctx, plan)
+}
+
+func (r *FsRegistry) apply(ctx context.Context, a Action) error {
+	switch a.Type {
+	case ActionRemove:
+		return r.Uninstall(a.Name)
+
+	case ActionInstall, ActionUpgrade:
+		return r.InstallRequest(ctx, installer.Request{Source: a.Source, Name: a.Name, Version: a.Version})
+
+	case ActionEnable:
+		return r.Enable(a.Name)
+
+	case ActionDisable:
+		return r.Disable(a.Name)
+	}
+
+	return ErrUnknownAction
+}
+
+func stateAction(name string, enabled bool) Action {
+	if enabled {
+		return Action{Type: ActionEnable, Name: name}
+	}
+
+	return Action{Type: ActionDisable, Name: name}
+}
+
+// satisfies checks whether the version satisfies the constraint. An invalid version does not satisfy any constraint
+// but the empty one.
+func satisfies(c plugin.Constraint, version string) bool {
+	if c.IsEmpty() {
+		return true
+	}
+
+	ok, err := c.CheckString(version)
+
+	return err == nil && ok
+}
@@ -0,0 +1,182 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	registry "github.com/nhatthm/plugin-registry"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestDesiredState_UnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	data := `
+plugins:
+    - name: plugin-a
+      source: github.com/example/plugin-a
+      version: ^1.4
+    - name: plugin-b
+      source: github.com/example/plugin-b
+      enabled: false
+`
+
+	var actual registry.DesiredState
+
+	err := yaml.Unmarshal([]byte(data), &actual)
+	require.NoError(t, err)
+
+	expected := registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "plugin-a", Source: "github.com/example/plugin-a", Version: plugin.MustParseConstraint("^1.4"), Enabled: true},
+		{Name: "plugin-b", Source: "github.com/example/plugin-b", Enabled: false},
+	}}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestRegistry_Reconcile(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/a", artifactPlugin("plugin-a", "v1.0.0"), artifactFile("plugin-a", "plugin a"))
+	installerMock.RegisterPlugin(source+"/a2", artifactPlugin("plugin-a", "v2.0.0"), artifactFile("plugin-a", "plugin a"))
+	installerMock.RegisterPlugin(source+"/b", artifactPlugin("plugin-b", "v1.0.0"), artifactFile("plugin-b", "plugin b"))
+	installerMock.RegisterPlugin(source+"/c", artifactPlugin("plugin-c", "v1.0.0"), artifactFile("plugin-c", "plugin c"))
+	installerMock.RegisterPlugin(source+"/d", artifactPlugin("plugin-d", "v1.0.0"), artifactFile("plugin-d", "plugin d"))
+
+	fs := afero.NewMemMapFs()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source+"/a"))
+	require.NoError(t, r.Install(context.Background(), source+"/b"))
+	require.NoError(t, r.Install(context.Background(), source+"/c"))
+
+	desired := registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "plugin-d", Source: source + "/d", Enabled: false},
+		{Name: "plugin-a", Source: source + "/a2", Version: plugin.MustParseConstraint("^2"), Enabled: true},
+		{Name: "plugin-b", Source: source + "/b", Version: plugin.MustParseConstraint("^1"), Enabled: false},
+	}}
+
+	expected := registry.Plan{Actions: []registry.Action{
+		{Type: registry.ActionRemove, Name: "plugin-c"},
+		{Type: registry.ActionUpgrade, Name: "plugin-a", Source: source + "/a2", Version: plugin.MustParseConstraint("^2")},
+		{Type: registry.ActionInstall, Name: "plugin-d", Source: source + "/d"},
+		{Type: registry.ActionDisable, Name: "plugin-b"},
+		{Type: registry.ActionDisable, Name: "plugin-d"},
+	}}
+
+	// Dry run.
+	plan, err := r.Reconcile(context.Background(), desired, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, plan)
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.Len(t, cfg.Plugins, 3)
+	assert.True(t, cfg.Plugins.Has("plugin-c"))
+
+	// Apply.
+	plan, err = r.Reconcile(context.Background(), desired, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, plan)
+
+	cfg, err = r.Config()
+	require.NoError(t, err)
+
+	assert.Len(t, cfg.Plugins, 3)
+	assert.Equal(t, "v2.0.0", cfg.Plugins["plugin-a"].Version)
+	assert.True(t, cfg.Plugins["plugin-a"].Enabled)
+	assert.False(t, cfg.Plugins["plugin-b"].Enabled)
+	assert.False(t, cfg.Plugins["plugin-d"].Enabled)
+
+	// Nothing to do.
+	plan, err = r.Plan(context.Background(), desired)
+	require.NoError(t, err)
+
+	assert.True(t, plan.IsEmpty())
+}
+
+func TestRegistry_Plan_MetadataURL(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source, &plugin.Plugin{
+		Name:    "my-plugin",
+		URL:     "https://github.com/acme/my-plugin",
+		Version: "v1.0.0",
+		Enabled: true,
+	})
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source))
+
+	// The installed plugin is compared to the source that it was installed from, not to the url of its metadata.
+	plan, err := r.Plan(context.Background(), registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "my-plugin", Source: source, Enabled: true},
+	}})
+	require.NoError(t, err)
+
+	assert.Empty(t, plan.Actions)
+}
+
+func TestRegistry_Plan_UnknownSource(t *testing.T) {
+	t.Parallel()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	_, err = r.Plan(context.Background(), registry.DesiredState{Plugins: []registry.DesiredPlugin{
+		{Name: "my-plugin", Source: "UNKNOWN"},
+	}})
+
+	require.EqualError(t, err, "could not plan plugin: no supported installer")
+}
+
+func TestRegistry_Apply_Error(t *testing.T) {
+	t.Parallel()
+
+	r, err := registry.NewRegistry("/tmp", registry.WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	err = r.Apply(context.Background(), registry.Plan{Actions: []registry.Action{
+		{Type: registry.ActionEnable, Name: "my-plugin"},
+	}})
+	require.EqualError(t, err, "could not apply action: plugin does not exist")
+
+	err = r.Apply(context.Background(), registry.Plan{Actions: []registry.Action{
+		{Type: "unknown", Name: "my-plugin"},
+	}})
+	require.ErrorIs(t, err, registry.ErrUnknownAction)
+}
+
+func TestAction_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "install my-plugin from github.com/example/my-plugin",
+		registry.Action{Type: registry.ActionInstall, Name: "my-plugin", Source: "github.com/example/my-plugin"}.String())
+
+	assert.Equal(t, "upgrade my-plugin@^1.4 from github.com/example/my-plugin",
+		registry.Action{
+			Type:    registry.ActionUpgrade,
+			Name:    "my-plugin",
+			Source:  "github.com/example/my-plugin",
+			Version: plugin.MustParseConstraint("^1.4"),
+		}.String())
+
+	assert.Equal(t, "remove my-plugin", registry.Action{Type: registry.ActionRemove, Name: "my-plugin"}.String())
+}
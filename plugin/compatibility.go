@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatible indicates that the plugin does not support the version of the host.
+var ErrIncompatible = errors.New("plugin is not compatible with the host")
+
+// Compatibility describes the versions of the host that the plugin supports.
+type Compatibility struct {
+	// Host is the constraint that the version of the host must satisfy. It is optional.
+	Host Constraint `yaml:"host,omitempty"`
+}
+
+// IncompatibleError describes a plugin that does not support the version of the host.
+type IncompatibleError struct {
+	Name       string
+	Version    string
+	Host       string
+	Constraint string
+}
+
+// Error satisfies error.
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("%s: %s %s requires host %s, got %s", ErrIncompatible, e.Name, e.Version, e.Constraint, e.Host)
+}
+
+// Unwrap returns ErrIncompatible.
+func (e *IncompatibleError) Unwrap() error {
+	return ErrIncompatible
+}
+
+// CheckCompatibility checks whether the plugin supports the version of the host. The error is an *IncompatibleError if
+// it does not.
+func (p *Plugin) CheckCompatibility(host Version) error {
+	if p.Compatibility.Host.Check(host) {
+		return nil
+	}
+
+	return &IncompatibleError{
+		Name:       p.Name,
+		Version:    p.Version,
+		Host:       host.String(),
+		Constraint: p.Compatibility.Host.String(),
+	}
+}
+
+// FilterIncompatible returns the plugins that do not support the version of the host.
+func (p Plugins) FilterIncompatible(host Version) Plugins {
+	result := make(Plugins, len(p))
+
+	for k, v := range p {
+		v := v
+
+		if v.CheckCompatibility(host) != nil {
+			result[k] = v
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPlugin_CheckCompatibility(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		constraint    string
+		host          string
+		expectedError string
+	}{
+		{
+			scenario: "no constraint",
+			host:     "1.0.0",
+		},
+		{
+			scenario:   "compatible",
+			constraint: ">=1.2 <2",
+			host:       "1.4.0",
+		},
+		{
+			scenario:      "incompatible",
+			constraint:    ">=1.2 <2",
+			host:          "2.0.0",
+			expectedError: "plugin is not compatible with the host: my-plugin v1.0.0 requires host >=1.2 <2, got 2.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p := Plugin{
+				Name:          "my-plugin",
+				Version:       "v1.0.0",
+				Compatibility: Compatibility{Host: MustParseConstraint(tc.constraint)},
+			}
+
+			err := p.CheckCompatibility(MustParseVersion(tc.host))
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+				require.ErrorIs(t, err, ErrIncompatible)
+
+				var incompatible *IncompatibleError
+
+				require.ErrorAs(t, err, &incompatible)
+				assert.Equal(t, "2.0.0", incompatible.Host)
+			}
+		})
+	}
+}
+
+func TestPlugins_FilterIncompatible(t *testing.T) {
+	t.Parallel()
+
+	plugins := Plugins{
+		"any":  {Name: "any"},
+		"v1":   {Name: "v1", Compatibility: Compatibility{Host: MustParseConstraint("^1")}},
+		"v1.5": {Name: "v1.5", Compatibility: Compatibility{Host: MustParseConstraint(">=1.5")}},
+	}
+
+	expected := Plugins{
+		"v1.5": {Name: "v1.5", Compatibility: Compatibility{Host: MustParseConstraint(">=1.5")}},
+	}
+
+	assert.Equal(t, expected, plugins.FilterIncompatible(MustParseVersion("1.4.0")))
+}
+
+func TestPlugin_UnmarshalYAML_Compatibility(t *testing.T) {
+	t.Parallel()
+
+	var p Plugin
+
+	err := yaml.Unmarshal([]byte("name: my-plugin\ncompatibility:\n    host: ^1.4\n"), &p)
+	require.NoError(t, err)
+
+	assert.Equal(t, MustParseConstraint("^1.4"), p.Compatibility.Host)
+
+	out, err := yaml.Marshal(Compatibility{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "{}\n", string(out))
+}
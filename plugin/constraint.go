@@ -0,0 +1,348 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConstraint indicates that the version constraint could not be parsed.
+var ErrInvalidConstraint = errors.New("invalid version constraint")
+
+type operator string
+
+const (
+	opEqual          operator = "="
+	opNotEqual       operator = "!="
+	opGreater        operator = ">"
+	opGreaterOrEqual operator = ">="
+	opLess           operator = "<"
+	opLessOrEqual    operator = "<="
+	opTilde          operator = "~"
+	opCaret          operator = "^"
+)
+
+// operators are sorted so that the longer operators are matched first.
+var operators = []operator{
+	opNotEqual, opGreaterOrEqual, opLessOrEqual, opGreater, opLess, opEqual, opTilde, opCaret,
+}
+
+type comparator struct {
+	op      operator
+	version Version
+}
+
+func (c comparator) check(v Version) bool {
+	r := v.Compare(c.version)
+
+	switch c.op {
+	case opNotEqual:
+		return r != 0
+	case opGreater:
+		return r > 0
+	case opGreaterOrEqual:
+		return r >= 0
+	case opLess:
+		return r < 0
+	case opLessOrEqual:
+		return r <= 0
+	default:
+		return r == 0
+	}
+}
+
+// Constraint is a version constraint, such as ">=1.2 <2", "^1.4", "~1.4.2" or "1.x || >=2.3".
+//
+// Comparators separated by spaces or commas must all be satisfied, "||" separates alternatives. A partial version
+// matches all the versions it covers, "1.2" is the same as ">=1.2.0 <1.3.0". The "^" operator allows changes that do
+// not modify the left-most non-zero number, and the "~" operator allows patch level changes if the minor version is
+// specified, or minor level changes if not. A pre-release version is only accepted if a comparator of the same
+// alternative has a pre-release of the same major, minor and patch versions.
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+// String satisfies fmt.Stringer.
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Check checks whether the version satisfies the constraint. An empty constraint is satisfied by any version.
+func (c Constraint) Check(v Version) bool {
+	if len(c.sets) == 0 {
+		return true
+	}
+
+	for _, set := range c.sets {
+		if checkAll(set, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckString parses the version and checks whether it satisfies the constraint.
+func (c Constraint) CheckString(version string) (bool, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Check(v), nil
+}
+
+// And returns a constraint that is satisfied by the versions that satisfy both constraints.
+func (c Constraint) And(other Constraint) Constraint {
+	if c.IsEmpty() {
+		return other
+	}
+
+	if other.IsEmpty() {
+		return c
+	}
+
+	left, right := strings.Split(c.raw, "||"), strings.Split(other.raw, "||")
+	result := Constraint{sets: make([][]comparator, 0, len(c.sets)*len(other.sets))}
+	raws := make([]string, 0, cap(result.sets))
+
+	for i, a := range c.sets {
+		for j, b := range other.sets {
+			set := make([]comparator, 0, len(a)+len(b))
+			set = append(set, a...)
+			set = append(set, b...)
+
+			result.sets = append(result.sets, set)
+			raws = append(raws, strings.TrimSpace(left[i])+" "+strings.TrimSpace(right[j]))
+		}
+	}
+
+	result.raw = strings.Join(raws, " || ")
+
+	return result
+}
+
+// IsEmpty checks whether the constraint accepts any version.
+func (c Constraint) IsEmpty() bool {
+	return len(c.sets) == 0
+}
+
+// IsZero satisfies yaml.IsZeroer, so that "omitempty" does not drop a non-empty constraint.
+func (c Constraint) IsZero() bool {
+	return c.raw == ""
+}
+
+// MarshalYAML satisfies yaml.Marshaler.
+func (c Constraint) MarshalYAML() (interface{}, error) { //nolint: unparam
+	return c.raw, nil
+}
+
+// UnmarshalYAML satisfies yaml.Unmarshaler.
+func (c *Constraint) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	parsed, err := ParseConstraint(raw)
+	if err != nil {
+		return err
+	}
+
+	*c = parsed
+
+	return nil
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+
+	if c.raw == "" || c.raw == "*" {
+		return c, nil
+	}
+
+	for _, alt := range strings.Split(c.raw, "||") {
+		set, err := parseComparators(alt)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%w: %q", ErrInvalidConstraint, s)
+		}
+
+		c.sets = append(c.sets, set)
+	}
+
+	return c, nil
+}
+
+// MustParseConstraint parses a version constraint and panics if it is invalid.
+func MustParseConstraint(s string) Constraint {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+func checkAll(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.check(v) {
+			return false
+		}
+	}
+
+	return v.PreRelease == "" || allowsPreRelease(set, v)
+}
+
+// allowsPreRelease checks whether a comparator of the set has a pre-release of the same major, minor and patch
+// versions, so that "^1.4" does not accept "2.0.0-alpha.1" but ">=1.4.0-rc.1" accepts "1.4.0-rc.2".
+func allowsPreRelease(set []comparator, v Version) bool {
+	for _, c := range set {
+		if c.version.PreRelease != "" &&
+			c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseComparators(s string) ([]comparator, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+
+	if len(fields) == 0 {
+		return nil, ErrInvalidConstraint
+	}
+
+	result := make([]comparator, 0, len(fields))
+
+	for i := 0; i < len(fields); i++ {
+		expr := fields[i]
+
+		// Allow a space between the operator and the version, such as ">= 1.2".
+		if isOperator(expr) && i+1 < len(fields) {
+			i++
+			expr += fields[i]
+		}
+
+		comparators, err := parseComparator(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, comparators...)
+	}
+
+	return result, nil
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == string(op) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	op := opEqual
+
+	for _, o := range operators {
+		if strings.HasPrefix(s, string(o)) {
+			op = o
+			s = s[len(o):]
+
+			break
+		}
+	}
+
+	if s == "*" || s == "x" || s == "X" {
+		if op == opEqual || op == opGreaterOrEqual || op == opLessOrEqual {
+			return nil, nil
+		}
+
+		return nil, ErrInvalidConstraint
+	}
+
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if parts == 0 {
+		return nil, ErrInvalidConstraint
+	}
+
+	switch op {
+	case opCaret:
+		return []comparator{{opGreaterOrEqual, v}, {opLess, caretUpperBound(v, parts)}}, nil
+
+	case opTilde:
+		return []comparator{{opGreaterOrEqual, v}, {opLess, nextVersion(v, minInt(parts, 2))}}, nil
+
+	case opEqual, opNotEqual:
+		if parts == 3 {
+			return []comparator{{op, v}}, nil
+		}
+
+		if op == opNotEqual {
+			// A partial version can not be excluded by a single comparator.
+			return nil, ErrInvalidConstraint
+		}
+
+		return []comparator{{opGreaterOrEqual, v}, {opLess, nextVersion(v, parts)}}, nil
+
+	case opGreater, opLessOrEqual:
+		if parts < 3 {
+			// ">1.2" means ">=1.3.0", and "<=1.2" means "<1.3.0".
+			next := nextVersion(v, parts)
+
+			if op == opGreater {
+				return []comparator{{opGreaterOrEqual, next}}, nil
+			}
+
+			return []comparator{{opLess, next}}, nil
+		}
+	}
+
+	return []comparator{{op, v}}, nil
+}
+
+// nextVersion increases the version at the given position, 1 is major and 2 is minor.
+func nextVersion(v Version, parts int) Version {
+	switch parts {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+func caretUpperBound(v Version, parts int) Version {
+	switch {
+	case v.Major > 0 || parts == 1:
+		return nextVersion(v, 1)
+	case v.Minor > 0 || parts == 2:
+		return nextVersion(v, 2)
+	default:
+		return nextVersion(v, 3)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
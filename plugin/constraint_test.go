@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseConstraint_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []string{
+		">=",
+		"1.2.3.4",
+		">=1.2 <",
+		"!=1.2",
+		">*",
+		"1.2 || ",
+		"latest",
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseConstraint(tc)
+
+			require.ErrorIs(t, err, ErrInvalidConstraint)
+		})
+	}
+}
+
+func TestConstraint_Check(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{constraint: "", version: "1.2.3", expected: true},
+		{constraint: "*", version: "0.0.1", expected: true},
+		{constraint: "1.2.3", version: "1.2.3", expected: true},
+		{constraint: "=v1.2.3", version: "1.2.4", expected: false},
+		{constraint: "!=1.2.3", version: "1.2.4", expected: true},
+		{constraint: "!=1.2.3", version: "1.2.3", expected: false},
+		{constraint: "1.2", version: "1.2.9", expected: true},
+		{constraint: "1.2.x", version: "1.3.0", expected: false},
+		{constraint: "1", version: "1.9.0", expected: true},
+		{constraint: ">=1.2 <2", version: "1.2.0", expected: true},
+		{constraint: ">=1.2 <2", version: "1.10.0", expected: true},
+		{constraint: ">=1.2 <2", version: "2.0.0", expected: false},
+		{constraint: ">=1.2 <2", version: "1.1.9", expected: false},
+		{constraint: ">= 1.2, < 2", version: "1.5.0", expected: true},
+		{constraint: ">1.2", version: "1.2.9", expected: false},
+		{constraint: ">1.2", version: "1.3.0", expected: true},
+		{constraint: ">1.2.3", version: "1.2.4", expected: true},
+		{constraint: "<=1.2", version: "1.2.9", expected: true},
+		{constraint: "<=1.2", version: "1.3.0", expected: false},
+		{constraint: "<=1.2.3", version: "1.2.3", expected: true},
+		{constraint: "^1.4", version: "1.4.0", expected: true},
+		{constraint: "^1.4", version: "1.9.2", expected: true},
+		{constraint: "^1.4", version: "2.0.0", expected: false},
+		{constraint: "^1.4", version: "1.3.9", expected: false},
+		{constraint: "^0.2.3", version: "0.2.9", expected: true},
+		{constraint: "^0.2.3", version: "0.3.0", expected: false},
+		{constraint: "^0.0.3", version: "0.0.4", expected: false},
+		{constraint: "~1.4.2", version: "1.4.9", expected: true},
+		{constraint: "~1.4.2", version: "1.5.0", expected: false},
+		{constraint: "~1", version: "1.9.0", expected: true},
+		{constraint: "~1", version: "2.0.0", expected: false},
+		{constraint: "1.x || >=2.3", version: "2.2.0", expected: false},
+		{constraint: "1.x || >=2.3", version: "2.3.0", expected: true},
+		{constraint: "1.x || >=2.3", version: "1.0.0", expected: true},
+		{constraint: ">=1.0.0-rc.1", version: "1.0.0-beta", expected: false},
+		{constraint: ">=1.0.0-rc.1", version: "1.0.0-rc.2", expected: true},
+		{constraint: ">=1.0.0-rc.1", version: "1.0.1-rc.1", expected: false},
+		{constraint: ">=1.0.0-rc.1", version: "1.0.1", expected: true},
+		{constraint: "^1.4", version: "2.0.0-alpha.1", expected: false},
+		{constraint: "^1.4", version: "1.5.0-beta", expected: false},
+		{constraint: "^1.4.0-beta", version: "1.4.0-rc.1", expected: true},
+		{constraint: "^1.4.0-beta", version: "2.0.0-alpha.1", expected: false},
+		{constraint: "1.x", version: "2.0.0-alpha.1", expected: false},
+		{constraint: "<2", version: "2.0.0-alpha.1", expected: false},
+		{constraint: "~1.4.2", version: "1.4.3-rc.1", expected: false},
+		{constraint: "=1.2.3-rc.1", version: "1.2.3-rc.1", expected: true},
+		{constraint: "1.x || >=2.0.0-rc.1", version: "2.0.0-rc.2", expected: true},
+		{constraint: "", version: "2.0.0-alpha.1", expected: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.constraint+"_"+tc.version, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := ParseConstraint(tc.constraint)
+			require.NoError(t, err)
+
+			actual, err := c.CheckString(tc.version)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestConstraint_And(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		left        string
+		right       string
+		expectedRaw string
+		accepted    []string
+		rejected    []string
+	}{
+		{left: "", right: "^1.2", expectedRaw: "^1.2", accepted: []string{"1.5.0"}, rejected: []string{"2.0.0"}},
+		{left: "^1.2", right: "", expectedRaw: "^1.2", accepted: []string{"1.5.0"}, rejected: []string{"2.0.0"}},
+		{left: "^1.2", right: "=1.2.5", expectedRaw: "^1.2 =1.2.5", accepted: []string{"1.2.5"}, rejected: []string{"1.9.0"}},
+		{left: "^2", right: "=1.2.5", expectedRaw: "^2 =1.2.5", rejected: []string{"1.2.5", "2.0.0"}},
+		{
+			left:        "1.x || 3.x",
+			right:       ">=1.5 || >=3.2",
+			expectedRaw: "1.x >=1.5 || 1.x >=3.2 || 3.x >=1.5 || 3.x >=3.2",
+			accepted:    []string{"1.5.0", "3.0.0"},
+			rejected:    []string{"1.4.0", "2.0.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.left+"_"+tc.right, func(t *testing.T) {
+			t.Parallel()
+
+			c := MustParseConstraint(tc.left).And(MustParseConstraint(tc.right))
+
+			assert.Equal(t, tc.expectedRaw, c.String())
+
+			for _, v := range tc.accepted {
+				assert.True(t, c.Check(MustParseVersion(v)), v)
+			}
+
+			for _, v := range tc.rejected {
+				assert.False(t, c.Check(MustParseVersion(v)), v)
+			}
+		})
+	}
+}
+
+func TestConstraint_CheckString_InvalidVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := MustParseConstraint("^1.0").CheckString("1.0")
+
+	require.ErrorIs(t, err, ErrInvalidVersion)
+}
+
+func TestConstraint_YAML(t *testing.T) {
+	t.Parallel()
+
+	var c struct {
+		Version Constraint `yaml:"version"`
+	}
+
+	err := yaml.Unmarshal([]byte(`version: ">=1.2 <2"`), &c)
+	require.NoError(t, err)
+
+	assert.Equal(t, ">=1.2 <2", c.Version.String())
+	assert.True(t, c.Version.Check(MustParseVersion("1.5.0")))
+
+	out, err := yaml.Marshal(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, "version: '>=1.2 <2'\n", string(out))
+
+	err = yaml.Unmarshal([]byte(`version: ">="`), &c)
+	require.ErrorIs(t, err, ErrInvalidConstraint)
+}
+
+func TestMustParseConstraint(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, MustParseConstraint("").IsEmpty())
+	assert.False(t, MustParseConstraint("^1").IsEmpty())
+
+	assert.Panics(t, func() {
+		MustParseConstraint(">=")
+	})
+}
+
+func TestConstraint_YAML_OmitEmpty(t *testing.T) {
+	t.Parallel()
+
+	type constraint struct {
+		Version Constraint `yaml:"version,omitempty"`
+	}
+
+	out, err := yaml.Marshal(constraint{Version: MustParseConstraint("^1.4")})
+	require.NoError(t, err)
+
+	assert.Equal(t, "version: ^1.4\n", string(out))
+
+	out, err = yaml.Marshal(constraint{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "{}\n", string(out))
+}
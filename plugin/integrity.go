@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// ChecksumSHA256 is the sha256 checksum algorithm.
+	ChecksumSHA256 = "sha256"
+	// ChecksumSHA512 is the sha512 checksum algorithm.
+	ChecksumSHA512 = "sha512"
+)
+
+var (
+	// ErrIntegrity indicates that the artifact is not what the metadata promised.
+	ErrIntegrity = errors.New("artifact integrity check failed")
+	// ErrUnsupportedChecksum indicates that the checksum algorithm is not supported.
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+)
+
+// IntegrityError describes a mismatch between an artifact and its metadata.
+type IntegrityError struct {
+	File     string
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// Error satisfies error.
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%s: %s of %q mismatch, expected %s, got %s", ErrIntegrity, e.Field, e.File, e.Expected, e.Actual)
+}
+
+// Unwrap returns ErrIntegrity.
+func (e *IntegrityError) Unwrap() error {
+	return ErrIntegrity
+}
+
+// HasIntegrity checks whether the artifact has information to verify its integrity.
+func (a Artifact) HasIntegrity() bool {
+	return a.Checksum != "" || a.Size > 0
+}
+
+// Verify verifies the size and the checksum of the artifact file at the given path.
+func (a Artifact) Verify(fs afero.Fs, path string) error {
+	if a.Size > 0 {
+		fi, err := fs.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if fi.Size() != a.Size {
+			return &IntegrityError{
+				File:     path,
+				Field:    "size",
+				Expected: strconv.FormatInt(a.Size, 10),
+				Actual:   strconv.FormatInt(fi.Size(), 10),
+			}
+		}
+	}
+
+	if a.Checksum == "" {
+		return nil
+	}
+
+	algorithm, expected := splitChecksum(a.Checksum)
+
+	actual, err := ComputeChecksum(fs, path, algorithm)
+	if err != nil {
+		return err
+	}
+
+	if _, value := splitChecksum(actual); !strings.EqualFold(value, expected) {
+		return &IntegrityError{
+			File:     path,
+			Field:    "checksum",
+			Expected: a.Checksum,
+			Actual:   actual,
+		}
+	}
+
+	return nil
+}
+
+// ComputeChecksum computes the checksum of a file, the result is prefixed by the algorithm, such as "sha256:<hex>".
+func ComputeChecksum(fs afero.Fs, path string, algorithm string) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint: errcheck
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return algorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedChecksum, algorithm)
+}
+
+// splitChecksum splits a checksum into algorithm and value. A checksum without algorithm is considered as sha256.
+func splitChecksum(checksum string) (string, string) {
+	parts := strings.SplitN(checksum, ":", 2)
+
+	if len(parts) == 1 {
+		return ChecksumSHA256, parts[0]
+	}
+
+	return strings.ToLower(parts[0]), parts[1]
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	helloSHA512 = "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"
+)
+
+func TestArtifact_Verify(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		artifact      Artifact
+		expectedError string
+	}{
+		{
+			scenario: "no integrity",
+			artifact: Artifact{File: "my-plugin"},
+		},
+		{
+			scenario: "valid size",
+			artifact: Artifact{File: "my-plugin", Size: 5},
+		},
+		{
+			scenario:      "invalid size",
+			artifact:      Artifact{File: "my-plugin", Size: 6},
+			expectedError: `artifact integrity check failed: size of "/tmp/my-plugin" mismatch, expected 6, got 5`,
+		},
+		{
+			scenario: "valid sha256",
+			artifact: Artifact{File: "my-plugin", Checksum: "sha256:" + helloSHA256, Size: 5},
+		},
+		{
+			scenario: "valid sha256 without prefix",
+			artifact: Artifact{File: "my-plugin", Checksum: helloSHA256},
+		},
+		{
+			scenario: "valid sha512",
+			artifact: Artifact{File: "my-plugin", Checksum: "SHA512:" + helloSHA512},
+		},
+		{
+			scenario:      "invalid checksum",
+			artifact:      Artifact{File: "my-plugin", Checksum: "sha256:abc"},
+			expectedError: `artifact integrity check failed: checksum of "/tmp/my-plugin" mismatch, expected sha256:abc, got sha256:` + helloSHA256,
+		},
+		{
+			scenario:      "unsupported algorithm",
+			artifact:      Artifact{File: "my-plugin", Checksum: "md5:abc"},
+			expectedError: `unsupported checksum algorithm: "md5"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			err := afero.WriteFile(fs, "/tmp/my-plugin", []byte("hello"), 0o755)
+			require.NoError(t, err)
+
+			err = tc.artifact.Verify(fs, "/tmp/my-plugin")
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestArtifact_Verify_IntegrityError(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "/tmp/my-plugin", []byte("hello"), 0o755)
+	require.NoError(t, err)
+
+	err = Artifact{Checksum: "sha256:abc"}.Verify(fs, "/tmp/my-plugin")
+
+	var integrityErr *IntegrityError
+
+	require.ErrorIs(t, err, ErrIntegrity)
+	require.ErrorAs(t, err, &integrityErr)
+	assert.Equal(t, "checksum", integrityErr.Field)
+}
+
+func TestArtifact_Verify_FileNotFound(t *testing.T) {
+	t.Parallel()
+
+	err := Artifact{Size: 5}.Verify(afero.NewMemMapFs(), "/tmp/my-plugin")
+	require.EqualError(t, err, "open /tmp/my-plugin: file does not exist")
+
+	err = Artifact{Checksum: "sha256:abc"}.Verify(afero.NewMemMapFs(), "/tmp/my-plugin")
+	require.EqualError(t, err, "open /tmp/my-plugin: file does not exist")
+}
+
+func TestArtifact_MarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	a := Artifact{File: "my-plugin", Checksum: "sha256:" + helloSHA256, Size: 5}
+
+	out, err := yaml.Marshal(a)
+	require.NoError(t, err)
+
+	expected := "file: my-plugin\nchecksum: sha256:" + helloSHA256 + "\nsize: 5\n"
+
+	assert.Equal(t, expected, string(out))
+
+	var actual Artifact
+
+	err = yaml.Unmarshal(out, &actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, actual)
+}
@@ -20,23 +20,27 @@ const (
 	defaultFile = "${name}-${version}-${os}-${arch}.tar.gz"
 )
 
-// ErrPluginNotExist indicates that the plugin does not exist.
-var ErrPluginNotExist = errors.New("plugin does not exist")
+var (
+	// ErrPluginNotExist indicates that the plugin does not exist.
+	ErrPluginNotExist = errors.New("plugin does not exist")
+	// ErrInvalidName indicates that the name of the plugin can not be used as a directory name.
+	ErrInvalidName = errors.New("invalid plugin name")
+	// ErrInvalidArtifactPath indicates that the artifact of the plugin is outside of the plugin directory.
+	ErrInvalidArtifactPath = errors.New("plugin artifact is outside of the plugin directory")
+)
+
+// IsValidName checks whether the name of a plugin can be used as the name of its directory. The names that start with
+// a dot are reserved for the registry.
+func IsValidName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
 
 // Plugins is a map of plugins.
 type Plugins map[string]Plugin
 
 // FilterByTag filter the plugins by tags.
 func (p Plugins) FilterByTag(tag string) Plugins {
-	result := make(Plugins, len(p))
-
-	for k, v := range p {
-		if v.Tags.Contains(tag) {
-			result[k] = v
-		}
-	}
-
-	return result
+	return p.Filter(HasAnyTag(tag))
 }
 
 // Has checks whether the plugin is in the map or not.
@@ -56,6 +60,23 @@ type Plugin struct {
 	Hidden      bool      `yaml:"hidden"`
 	Artifacts   Artifacts `yaml:"artifacts"`
 	Tags        Tags      `yaml:"tags"`
+	// Requires is the list of plugins that the plugin depends on.
+	Requires Requirements `yaml:"requires,omitempty"`
+	// Compatibility describes the versions of the host that the plugin supports.
+	Compatibility Compatibility `yaml:"compatibility,omitempty"`
+	// SignedBy is the fingerprint of the trusted key that signed the plugin, it is set by the registry.
+	SignedBy string `yaml:"signed_by,omitempty"`
+	// Source is the source that the plugin was installed from, it is set by the registry to upgrade the plugin. Unlike
+	// the URL of the metadata, it is resolved by the installers.
+	Source string `yaml:"source,omitempty"`
+	// Revision is the revision of the source that the plugin was installed from, such as a git commit, it is set by the
+	// installer. A different revision of the same version is an upgrade.
+	Revision string `yaml:"revision,omitempty"`
+}
+
+// SemVer parses the version of the plugin.
+func (p *Plugin) SemVer() (Version, error) {
+	return ParseVersion(p.Version)
 }
 
 // RuntimeArtifact returns the artifact of current arch.
@@ -68,7 +89,7 @@ func (p *Plugin) RuntimeArtifact() Artifact {
 		return a
 	}
 
-	return Artifact{File: defaultFile}
+	return defaultArtifact()
 }
 
 // ResolveArtifact replaces all placeholders in artifact definition by real values.
@@ -86,6 +107,20 @@ func (p *Plugin) ResolveArtifact(a Artifact) Artifact {
 	return a
 }
 
+// RuntimeArtifactPath returns the path of the runtime artifact in the plugin directory. The artifact must be a file
+// inside the directory, or ErrInvalidArtifactPath is returned.
+func (p *Plugin) RuntimeArtifactPath(pluginDir string) (string, error) {
+	a := p.ResolveArtifact(p.RuntimeArtifact())
+	path := filepath.Join(pluginDir, filepath.FromSlash(a.File))
+
+	if rel, err := filepath.Rel(pluginDir, path); err != nil || rel == "." || rel == ".." ||
+		strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidArtifactPath, a.File)
+	}
+
+	return path, nil
+}
+
 // UnmarshalYAML satisfies yaml.Unmarshaler.
 func (p *Plugin) UnmarshalYAML(value *yaml.Node) error {
 	type rawPlugin Plugin
@@ -98,7 +133,7 @@ func (p *Plugin) UnmarshalYAML(value *yaml.Node) error {
 
 	if !raw.Artifacts.Has(RuntimeArtifactIdentifier()) &&
 		!raw.Artifacts.Has(RuntimeArtifactIdentifierWithoutArch()) {
-		raw.Artifacts[RuntimeArtifactIdentifier()] = Artifact{File: defaultFile}
+		raw.Artifacts[RuntimeArtifactIdentifier()] = defaultArtifact()
 	}
 
 	*p = Plugin(raw)
@@ -185,6 +220,15 @@ func (a *ArtifactIdentifier) UnmarshalYAML(value *yaml.Node) error {
 // Artifact represents all information about an artifact of a plugin.
 type Artifact struct {
 	File string `yaml:"file"`
+	// Checksum is the checksum of the file, prefixed by the algorithm, such as "sha256:<hex>". It is optional.
+	Checksum string `yaml:"checksum,omitempty"`
+	// Size is the size of the file in bytes. It is optional.
+	Size int64 `yaml:"size,omitempty"`
+}
+
+// defaultArtifact is the artifact of the plugins that do not declare one for the current platform.
+func defaultArtifact() Artifact {
+	return Artifact{File: defaultFile}
 }
 
 func defaultPluginConfig() Plugin {
@@ -233,6 +277,13 @@ func Load(fs afero.Fs, path string) (*Plugin, error) {
 		return nil, loadError(err, path)
 	}
 
+	// Only the metadata is checked, the configuration of the plugins installed before may have any version.
+	if p.Version != "" {
+		if _, err := ParseVersion(p.Version); err != nil {
+			return nil, loadError(err, path)
+		}
+	}
+
 	return &p, nil
 }
 
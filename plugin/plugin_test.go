@@ -3,6 +3,7 @@ package plugin
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -134,6 +135,68 @@ func TestPlugin_ResolveArtifact(t *testing.T) {
 	assert.Equal(t, expected, p.ResolveArtifact(a))
 }
 
+func TestPlugin_RuntimeArtifactPath(t *testing.T) {
+	t.Parallel()
+
+	pluginDir := filepath.Join("plugins", "my-plugin")
+
+	testCases := []struct {
+		scenario      string
+		file          string
+		expectedPath  string
+		expectedError error
+	}{
+		{
+			scenario:     "resolved",
+			file:         "${name}-${version}",
+			expectedPath: filepath.Join(pluginDir, "my-plugin-v1.0.0"),
+		},
+		{
+			scenario:     "sub directory",
+			file:         "bin/my-plugin",
+			expectedPath: filepath.Join(pluginDir, "bin", "my-plugin"),
+		},
+		{
+			scenario:      "plugin directory",
+			file:          ".",
+			expectedError: ErrInvalidArtifactPath,
+		},
+		{
+			scenario:      "parent directory",
+			file:          "..",
+			expectedError: ErrInvalidArtifactPath,
+		},
+		{
+			scenario:      "outside of plugin directory",
+			file:          "../other-plugin/other",
+			expectedError: ErrInvalidArtifactPath,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p := Plugin{
+				Name:      "my-plugin",
+				Version:   "v1.0.0",
+				Artifacts: Artifacts{RuntimeArtifactIdentifier(): {File: tc.file}},
+			}
+
+			path, err := p.RuntimeArtifactPath(pluginDir)
+
+			assert.Equal(t, tc.expectedPath, path)
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
 func TestPlugin_MarshalYAML(t *testing.T) {
 	t.Parallel()
 
@@ -475,6 +538,34 @@ func TestTags_Contains(t *testing.T) {
 	})
 }
 
+func TestIsValidName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "my-plugin", expected: true},
+		{name: "my.plugin", expected: true},
+		{name: ""},
+		{name: "."},
+		{name: ".."},
+		{name: ".stage-123"},
+		{name: "../my-plugin"},
+		{name: "my/plugin"},
+		{name: `my\plugin`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, IsValidName(tc.name))
+		})
+	}
+}
+
 func TestRuntimeArtifactIdentifier(t *testing.T) {
 	t.Parallel()
 
@@ -510,6 +601,16 @@ func TestLoad(t *testing.T) {
 			},
 			expectedError: "could not read metadata: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into plugin.rawPlugin",
 		},
+		{
+			scenario: "invalid version",
+			makeFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("version: 1.x"), 0o755) //nolint: errcheck
+
+				return fs
+			},
+			expectedError: `could not read metadata: invalid version: "1.x"`,
+		},
 		{
 			scenario: "success",
 			makeFs: func() afero.Fs {
@@ -548,6 +649,22 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestPlugin_SemVer(t *testing.T) {
+	t.Parallel()
+
+	p := Plugin{Version: "v1.2.3-rc.1"}
+
+	v, err := p.SemVer()
+	require.NoError(t, err)
+
+	assert.Equal(t, Version{Major: 1, Minor: 2, Patch: 3, PreRelease: "rc.1"}, v)
+
+	p = Plugin{Version: "latest"}
+
+	_, err = p.SemVer()
+	require.ErrorIs(t, err, ErrInvalidVersion)
+}
+
 func TestLoadError(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"path"
+	"sort"
+)
+
+// Filter tells whether a plugin is selected or not.
+type Filter func(p Plugin) bool
+
+// Filter returns the plugins that match all the filters.
+func (p Plugins) Filter(filters ...Filter) Plugins {
+	result := make(Plugins, len(p))
+
+	for k, v := range p {
+		if matchAll(v, filters) {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// Query returns the plugins that match all the filters, sorted by name.
+//
+//	plugins := cfg.Plugins.Query(plugin.IsEnabled(true), plugin.HasAnyTag("tools", "linters"))
+func (p Plugins) Query(filters ...Filter) []Plugin {
+	result := make([]Plugin, 0, len(p))
+
+	for _, v := range p {
+		if matchAll(v, filters) {
+			result = append(result, v)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// IsEnabled selects the enabled plugins, or the disabled ones.
+func IsEnabled(enabled bool) Filter {
+	return func(p Plugin) bool {
+		return p.Enabled == enabled
+	}
+}
+
+// IsHidden selects the hidden plugins, or the visible ones.
+func IsHidden(hidden bool) Filter {
+	return func(p Plugin) bool {
+		return p.Hidden == hidden
+	}
+}
+
+// HasAnyTag selects the plugins that have at least one of the tags.
+func HasAnyTag(tags ...string) Filter {
+	return func(p Plugin) bool {
+		for _, t := range tags {
+			if p.Tags.Contains(t) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// HasAllTags selects the plugins that have all the tags.
+func HasAllTags(tags ...string) Filter {
+	return func(p Plugin) bool {
+		for _, t := range tags {
+			if !p.Tags.Contains(t) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// NameMatches selects the plugins whose name matches the glob pattern, see path.Match(). An invalid pattern does not
+// match any plugin.
+func NameMatches(pattern string) Filter {
+	return func(p Plugin) bool {
+		ok, err := path.Match(pattern, p.Name)
+
+		return err == nil && ok
+	}
+}
+
+// VersionSatisfies selects the plugins whose version satisfies the constraint. An invalid version does not satisfy
+// any constraint but the empty one.
+func VersionSatisfies(c Constraint) Filter {
+	return func(p Plugin) bool {
+		if c.IsEmpty() {
+			return true
+		}
+
+		ok, err := c.CheckString(p.Version)
+
+		return err == nil && ok
+	}
+}
+
+// HasRuntimeArtifact selects the plugins that declare an artifact for the current os and arch, or for the current os.
+// The default artifact that is added when decoding a plugin without one for the current platform is not declared.
+func HasRuntimeArtifact() Filter {
+	return func(p Plugin) bool {
+		if a, ok := p.Artifacts[RuntimeArtifactIdentifier()]; ok && a != defaultArtifact() {
+			return true
+		}
+
+		return p.Artifacts.Has(RuntimeArtifactIdentifierWithoutArch())
+	}
+}
+
+// Not selects the plugins that do not match the filter.
+func Not(f Filter) Filter {
+	return func(p Plugin) bool {
+		return !f(p)
+	}
+}
+
+// Or selects the plugins that match at least one of the filters.
+func Or(filters ...Filter) Filter {
+	return func(p Plugin) bool {
+		for _, f := range filters {
+			if f(p) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func matchAll(p Plugin, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(p) {
+			return false
+		}
+	}
+
+	return true
+}
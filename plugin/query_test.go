@@ -0,0 +1,198 @@
+package plugin
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func queryFixtures() Plugins {
+	return Plugins{
+		"my-plugin": {
+			Name:      "my-plugin",
+			Version:   "v1.2.0",
+			Enabled:   true,
+			Tags:      Tags{"tools", "linters"},
+			Artifacts: Artifacts{RuntimeArtifactIdentifier(): {File: "my-plugin"}},
+		},
+		"my-hidden-plugin": {
+			Name:      "my-hidden-plugin",
+			Version:   "v2.0.0",
+			Enabled:   true,
+			Hidden:    true,
+			Tags:      Tags{"tools"},
+			Artifacts: Artifacts{RuntimeArtifactIdentifierWithoutArch(): {File: "my-hidden-plugin"}},
+		},
+		"other-plugin": {
+			Name:      "other-plugin",
+			Version:   "invalid",
+			Tags:      Tags{"linters"},
+			Artifacts: Artifacts{NewArtifactIdentifier("plan9", "arm"): {File: "other-plugin"}},
+		},
+	}
+}
+
+func TestPlugins_Query(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		filters  []Filter
+		expected []string
+	}{
+		{
+			scenario: "no filter",
+			expected: []string{"my-hidden-plugin", "my-plugin", "other-plugin"},
+		},
+		{
+			scenario: "enabled",
+			filters:  []Filter{IsEnabled(true)},
+			expected: []string{"my-hidden-plugin", "my-plugin"},
+		},
+		{
+			scenario: "disabled",
+			filters:  []Filter{IsEnabled(false)},
+			expected: []string{"other-plugin"},
+		},
+		{
+			scenario: "visible",
+			filters:  []Filter{IsHidden(false)},
+			expected: []string{"my-plugin", "other-plugin"},
+		},
+		{
+			scenario: "any tag",
+			filters:  []Filter{HasAnyTag("linters", "unknown")},
+			expected: []string{"my-plugin", "other-plugin"},
+		},
+		{
+			scenario: "all tags",
+			filters:  []Filter{HasAllTags("linters", "tools")},
+			expected: []string{"my-plugin"},
+		},
+		{
+			scenario: "name glob",
+			filters:  []Filter{NameMatches("my-*")},
+			expected: []string{"my-hidden-plugin", "my-plugin"},
+		},
+		{
+			scenario: "invalid name glob",
+			filters:  []Filter{NameMatches("[")},
+			expected: []string{},
+		},
+		{
+			scenario: "version constraint",
+			filters:  []Filter{VersionSatisfies(MustParseConstraint("^1"))},
+			expected: []string{"my-plugin"},
+		},
+		{
+			scenario: "empty version constraint",
+			filters:  []Filter{VersionSatisfies(Constraint{})},
+			expected: []string{"my-hidden-plugin", "my-plugin", "other-plugin"},
+		},
+		{
+			scenario: "runtime artifact",
+			filters:  []Filter{HasRuntimeArtifact()},
+			expected: []string{"my-hidden-plugin", "my-plugin"},
+		},
+		{
+			scenario: "not",
+			filters:  []Filter{Not(HasRuntimeArtifact())},
+			expected: []string{"other-plugin"},
+		},
+		{
+			scenario: "or",
+			filters:  []Filter{Or(IsHidden(true), IsEnabled(false))},
+			expected: []string{"my-hidden-plugin", "other-plugin"},
+		},
+		{
+			scenario: "combined",
+			filters:  []Filter{IsEnabled(true), IsHidden(false), HasAnyTag("tools")},
+			expected: []string{"my-plugin"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			result := queryFixtures().Query(tc.filters...)
+			actual := make([]string, 0, len(result))
+
+			for _, p := range result {
+				actual = append(actual, p.Name)
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestPlugins_Filter(t *testing.T) {
+	t.Parallel()
+
+	plugins := queryFixtures()
+
+	expected := Plugins{
+		"my-plugin":        plugins["my-plugin"],
+		"my-hidden-plugin": plugins["my-hidden-plugin"],
+	}
+
+	assert.Equal(t, expected, plugins.Filter(IsEnabled(true)))
+}
+
+func TestHasRuntimeArtifact_Decoded(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario string
+		metadata string
+		expected bool
+	}{
+		{
+			scenario: "no artifact",
+			metadata: "name: my-plugin\n",
+		},
+		{
+			scenario: "other platform",
+			metadata: "name: my-plugin\nartifacts:\n    plan9/386:\n        file: my-plugin.exe\n",
+		},
+		{
+			scenario: "current os and arch",
+			metadata: fmt.Sprintf("name: my-plugin\nartifacts:\n    %s/%s:\n        file: my-plugin\n", runtime.GOOS, runtime.GOARCH),
+			expected: true,
+		},
+		{
+			scenario: "current os",
+			metadata: fmt.Sprintf("name: my-plugin\nartifacts:\n    %s:\n        file: my-plugin\n", runtime.GOOS),
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			var p Plugin
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.metadata), &p))
+
+			assert.Equal(t, tc.expected, HasRuntimeArtifact()(p))
+
+			// The plugin is still the same once recorded in the configuration.
+			data, err := yaml.Marshal(p)
+			require.NoError(t, err)
+
+			var recorded Plugin
+
+			require.NoError(t, yaml.Unmarshal(data, &recorded))
+
+			assert.Equal(t, tc.expected, HasRuntimeArtifact()(recorded))
+		})
+	}
+}
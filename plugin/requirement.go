@@ -0,0 +1,24 @@
+package plugin
+
+// Requirement is a plugin that is required by another plugin.
+type Requirement struct {
+	Name string `yaml:"name"`
+	// Version is the constraint that the version of the required plugin must satisfy. It is optional.
+	Version Constraint `yaml:"version,omitempty"`
+	// Source is where to install the required plugin from if it is not installed. It is optional.
+	Source string `yaml:"source,omitempty"`
+}
+
+// Requirements is a list of required plugins.
+type Requirements []Requirement
+
+// Has checks whether the plugin is required.
+func (r Requirements) Has(name string) bool {
+	for _, req := range r {
+		if req.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPlugin_UnmarshalYAML_Requires(t *testing.T) {
+	t.Parallel()
+
+	data := `
+name: my-plugin
+requires:
+    - name: other-plugin
+      version: ^1.4
+      source: github.com/example/other-plugin
+    - name: another-plugin
+`
+
+	var p Plugin
+
+	err := yaml.Unmarshal([]byte(data), &p)
+	require.NoError(t, err)
+
+	expected := Requirements{
+		{Name: "other-plugin", Version: MustParseConstraint("^1.4"), Source: "github.com/example/other-plugin"},
+		{Name: "another-plugin"},
+	}
+
+	assert.Equal(t, expected, p.Requires)
+
+	err = yaml.Unmarshal([]byte("name: my-plugin\nrequires:\n    - name: other-plugin\n      version: '>='\n"), &p)
+	require.ErrorIs(t, err, ErrInvalidConstraint)
+}
+
+func TestRequirements_Has(t *testing.T) {
+	t.Parallel()
+
+	r := Requirements{{Name: "other-plugin"}}
+
+	assert.True(t, r.Has("other-plugin"))
+	assert.False(t, r.Has("another-plugin"))
+}
@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// SignatureFile is the detached signature of the plugin metadata file.
+const SignatureFile = MetadataFile + ".sig"
+
+var (
+	// ErrUnsigned indicates that the plugin has no signature.
+	ErrUnsigned = errors.New("plugin is not signed")
+	// ErrUntrusted indicates that the plugin is not signed by any of the trusted keys.
+	ErrUntrusted = errors.New("plugin is not signed by a trusted key")
+)
+
+// KeyFingerprint returns the fingerprint of a public key, such as "SHA256:<base64>".
+func KeyFingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// Sign signs the metadata file in the plugin directory and writes the detached signature next to it.
+func Sign(fs afero.Fs, path string, key ed25519.PrivateKey) error {
+	data, err := afero.ReadFile(fs, filepath.Join(path, MetadataFile))
+	if err != nil {
+		return err
+	}
+
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+
+	return afero.WriteFile(fs, filepath.Join(path, SignatureFile), []byte(sig+"\n"), os.FileMode(0o644))
+}
+
+// VerifySignature verifies the detached signature of the metadata file in the plugin directory against the trusted
+// keys, and returns the key that signed it. Because the metadata contains the checksum of the artifacts, a valid
+// signature also covers the artifacts.
+func VerifySignature(fs afero.Fs, path string, trustedKeys []ed25519.PublicKey) (ed25519.PublicKey, error) {
+	raw, err := afero.ReadFile(fs, filepath.Join(path, SignatureFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrUnsigned
+		}
+
+		return nil, err
+	}
+
+	data, err := afero.ReadFile(fs, filepath.Join(path, MetadataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, ErrUntrusted
+	}
+
+	for _, key := range trustedKeys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, data, sig) {
+			return key, nil
+		}
+	}
+
+	return nil, ErrUntrusted
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	trustedKey := newTestKey(1)
+	untrustedKey := newTestKey(2)
+
+	testCases := []struct {
+		scenario      string
+		makeFs        func(t *testing.T) afero.Fs
+		expectedKey   ed25519.PublicKey
+		expectedError error
+	}{
+		{
+			scenario: "unsigned",
+			makeFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: my-plugin"), 0o644))
+
+				return fs
+			},
+			expectedError: ErrUnsigned,
+		},
+		{
+			scenario: "malformed signature",
+			makeFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: my-plugin"), 0o644))
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml.sig", []byte("not base64"), 0o644))
+
+				return fs
+			},
+			expectedError: ErrUntrusted,
+		},
+		{
+			scenario: "signed by an untrusted key",
+			makeFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: my-plugin"), 0o644))
+				require.NoError(t, Sign(fs, "/tmp", untrustedKey))
+
+				return fs
+			},
+			expectedError: ErrUntrusted,
+		},
+		{
+			scenario: "metadata is modified after signing",
+			makeFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: my-plugin"), 0o644))
+				require.NoError(t, Sign(fs, "/tmp", trustedKey))
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: evil-plugin"), 0o644))
+
+				return fs
+			},
+			expectedError: ErrUntrusted,
+		},
+		{
+			scenario: "signed by a trusted key",
+			makeFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "/tmp/.plugin.registry.yaml", []byte("name: my-plugin"), 0o644))
+				require.NoError(t, Sign(fs, "/tmp", trustedKey))
+
+				return fs
+			},
+			expectedKey: trustedKey.Public().(ed25519.PublicKey),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			keys := []ed25519.PublicKey{
+				newTestKey(3).Public().(ed25519.PublicKey),
+				trustedKey.Public().(ed25519.PublicKey),
+			}
+
+			key, err := VerifySignature(tc.makeFs(t), "/tmp", keys)
+
+			assert.Equal(t, tc.expectedKey, key)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	t.Parallel()
+
+	key := newTestKey(1).Public().(ed25519.PublicKey)
+
+	assert.Regexp(t, `^SHA256:[A-Za-z0-9+/]{43}$`, KeyFingerprint(key))
+	assert.NotEqual(t, KeyFingerprint(key), KeyFingerprint(newTestKey(2).Public().(ed25519.PublicKey)))
+}
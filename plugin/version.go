@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion indicates that the version is not a valid semantic version.
+var ErrInvalidVersion = errors.New("invalid version")
+
+// Version is a semantic version, see https://semver.org.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	PreRelease string
+	Build      string
+}
+
+// String satisfies fmt.Stringer.
+func (v Version) String() string {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintf(&sb, "%d.%d.%d", v.Major, v.Minor, v.Patch) //nolint: errcheck
+
+	if v.PreRelease != "" {
+		sb.WriteString("-")
+		sb.WriteString(v.PreRelease)
+	}
+
+	if v.Build != "" {
+		sb.WriteString("+")
+		sb.WriteString(v.Build)
+	}
+
+	return sb.String()
+}
+
+// Compare compares two versions, the build metadata is ignored. The result is 0 if v == o, -1 if v < o, and +1 if
+// v > o.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	return comparePreRelease(v.PreRelease, o.PreRelease)
+}
+
+// Equal checks whether two versions have the same precedence.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// LessThan checks whether the version is lower than the other one.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// GreaterThan checks whether the version is greater than the other one.
+func (v Version) GreaterThan(o Version) bool {
+	return v.Compare(o) > 0
+}
+
+// ParseVersion parses a semantic version, with or without the "v" prefix.
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartialVersion(s)
+	if err != nil {
+		return Version{}, err
+	}
+
+	if parts != 3 {
+		return Version{}, invalidVersionError(s)
+	}
+
+	return v, nil
+}
+
+// MustParseVersion parses a semantic version and panics if it is invalid.
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// parsePartialVersion parses a version that may miss the minor and patch numbers, such as "1" or "1.2". Wildcards "x",
+// "X" and "*" are considered as missing. It returns the number of numeric parts that are present.
+func parsePartialVersion(s string) (Version, int, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	var v Version
+
+	if i := strings.Index(raw, "+"); i >= 0 {
+		v.Build = raw[i+1:]
+		raw = raw[:i]
+
+		if !isValidIdentifiers(v.Build, false) {
+			return Version{}, 0, invalidVersionError(s)
+		}
+	}
+
+	if i := strings.Index(raw, "-"); i >= 0 {
+		v.PreRelease = raw[i+1:]
+		raw = raw[:i]
+
+		if !isValidIdentifiers(v.PreRelease, true) {
+			return Version{}, 0, invalidVersionError(s)
+		}
+	}
+
+	numbers := strings.Split(raw, ".")
+	if len(numbers) > 3 {
+		return Version{}, 0, invalidVersionError(s)
+	}
+
+	parts := 0
+	fields := []*uint64{&v.Major, &v.Minor, &v.Patch}
+
+	for i, n := range numbers {
+		if n == "x" || n == "X" || n == "*" {
+			break
+		}
+
+		if !isNumeric(n) || (len(n) > 1 && n[0] == '0') {
+			return Version{}, 0, invalidVersionError(s)
+		}
+
+		num, err := strconv.ParseUint(n, 10, 64)
+		if err != nil {
+			return Version{}, 0, invalidVersionError(s)
+		}
+
+		*fields[i] = num
+		parts++
+	}
+
+	if parts < 3 && (v.PreRelease != "" || v.Build != "") {
+		return Version{}, 0, invalidVersionError(s)
+	}
+
+	return v, parts, nil
+}
+
+func invalidVersionError(s string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+}
+
+func isValidIdentifiers(s string, noLeadingZero bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+
+		for _, r := range id {
+			if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && r != '-' {
+				return false
+			}
+		}
+
+		if noLeadingZero && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePreRelease compares two pre-release versions. A version without pre-release has a higher precedence.
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+// compareIdentifier compares two pre-release identifiers. Numeric identifiers have a lower precedence than
+// alphanumeric ones.
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNum && bNum:
+		if c := compareUint(uint64(len(a)), uint64(len(b))); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+
+	case aNum:
+		return -1
+
+	case bNum:
+		return 1
+
+	default:
+		return strings.Compare(a, b)
+	}
+}
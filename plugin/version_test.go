@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		version       string
+		expected      Version
+		expectedError string
+	}{
+		{version: "1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{version: "v1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		{version: "v1.10.0-rc.1", expected: Version{Major: 1, Minor: 10, PreRelease: "rc.1"}},
+		{version: "1.0.0-alpha+build.42", expected: Version{Major: 1, PreRelease: "alpha", Build: "build.42"}},
+		{version: "1.0.0+20230101", expected: Version{Major: 1, Build: "20230101"}},
+		{version: "", expectedError: `invalid version: ""`},
+		{version: "1.2", expectedError: `invalid version: "1.2"`},
+		{version: "1.2.3.4", expectedError: `invalid version: "1.2.3.4"`},
+		{version: "01.2.3", expectedError: `invalid version: "01.2.3"`},
+		{version: "1.2.3-01", expectedError: `invalid version: "1.2.3-01"`},
+		{version: "1.2.3-rc..1", expectedError: `invalid version: "1.2.3-rc..1"`},
+		{version: "1.2.3+", expectedError: `invalid version: "1.2.3+"`},
+		{version: "latest", expectedError: `invalid version: "latest"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.version, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := ParseVersion(tc.version)
+
+			assert.Equal(t, tc.expected, v)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+				require.ErrorIs(t, err, ErrInvalidVersion)
+			}
+		})
+	}
+}
+
+func TestMustParseVersion(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Version{Major: 1, Minor: 2, Patch: 3}, MustParseVersion("v1.2.3"))
+
+	assert.Panics(t, func() {
+		MustParseVersion("1.2")
+	})
+}
+
+func TestVersion_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "1.2.3", MustParseVersion("v1.2.3").String())
+	assert.Equal(t, "1.2.3-rc.1+build.1", MustParseVersion("1.2.3-rc.1+build.1").String())
+}
+
+func TestVersion_Compare(t *testing.T) {
+	t.Parallel()
+
+	// Sorted by precedence, see https://semver.org/#spec-item-11.
+	versions := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.9.0",
+		"1.10.0",
+		"2.0.0",
+	}
+
+	for i := range versions {
+		for j := range versions {
+			a, b := MustParseVersion(versions[i]), MustParseVersion(versions[j])
+
+			switch {
+			case i < j:
+				assert.True(t, a.LessThan(b), "%s < %s", a, b)
+				assert.Equal(t, -1, a.Compare(b))
+			case i > j:
+				assert.True(t, a.GreaterThan(b), "%s > %s", a, b)
+				assert.Equal(t, 1, a.Compare(b))
+			default:
+				assert.True(t, a.Equal(b), "%s == %s", a, b)
+			}
+		}
+	}
+
+	assert.True(t, MustParseVersion("1.0.0+build.1").Equal(MustParseVersion("1.0.0+build.2")))
+}
@@ -2,14 +2,25 @@ package registry
 
 import (
 	"context"
+	"crypto/ed25519"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/spf13/afero"
 
 	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/index"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/lock"
 	"github.com/nhatthm/plugin-registry/plugin"
 )
 
+const lockFile = ".lock"
+
+// ErrRegistryLocked indicates that the registry is being modified by another process.
+var ErrRegistryLocked = lock.ErrLocked
+
 // Option configures Registry.
 type Option func(r *FsRegistry)
 
@@ -18,8 +29,18 @@ type Registry interface {
 	Config() (config.Configuration, error)
 	Enable(name string) error
 	Disable(name string) error
+	ForceDisable(name string) error
 	Install(ctx context.Context, src string) error
+	InstallRequest(ctx context.Context, req installer.Request) error
 	Uninstall(name string) error
+	ForceUninstall(name string) error
+	Upgrade(ctx context.Context, name string) error
+	UpgradeAll(ctx context.Context) error
+}
+
+// reloader is a configurator that caches the configuration, such as config.MemConfigurator.
+type reloader interface {
+	Reload() error
 }
 
 // FsRegistry is a file system plugin registry.
@@ -27,8 +48,19 @@ type FsRegistry struct {
 	fs     afero.Fs
 	config config.Configurator
 
-	path       string
-	configFile string
+	path        string
+	configFile  string
+	lockTimeout time.Duration
+	backup      bool
+
+	trustedKeys     []ed25519.PublicKey
+	signaturePolicy SignaturePolicy
+
+	hostVersion *plugin.Version
+	index       *index.Index
+
+	subscriptionsMu sync.Mutex
+	subscriptions   []*subscription
 }
 
 // Config returns the configuration of the registry.
@@ -51,11 +83,43 @@ func (r *FsRegistry) GetPlugin(name string) (*plugin.Plugin, error) {
 	return &p, nil
 }
 
+// lock acquires the lock of the registry to prevent other processes from modifying it.
+func (r *FsRegistry) lock(ctx context.Context) (func(), error) {
+	if err := r.fs.MkdirAll(r.path, 0o755); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.lockTimeout)
+	defer cancel()
+
+	l := lock.New(r.fs, filepath.Join(r.path, lockFile))
+
+	if err := l.Lock(ctx); err != nil {
+		return nil, err
+	}
+
+	release := func() {
+		_ = l.Unlock() //nolint: errcheck
+	}
+
+	// Another process may have changed the registry while the lock was not held.
+	if c, ok := r.config.(reloader); ok {
+		if err := c.Reload(); err != nil {
+			release()
+
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
 // NewRegistry initiates a new plugin registry.
 func NewRegistry(path string, options ...Option) (*FsRegistry, error) {
 	r := &FsRegistry{
-		fs:   afero.NewOsFs(),
-		path: filepath.Clean(path),
+		fs:          afero.NewOsFs(),
+		path:        filepath.Clean(path),
+		lockTimeout: config.DefaultLockTimeout,
 	}
 
 	for _, o := range options {
@@ -67,10 +131,15 @@ func NewRegistry(path string, options ...Option) (*FsRegistry, error) {
 	}
 
 	if r.config == nil {
-		c, err := config.NewMemConfigurator(
-			config.NewFileConfigurator(r.configFile).
-				WithFs(r.fs),
-		)
+		fc := config.NewFileConfigurator(r.configFile).
+			WithFs(r.fs).
+			WithLockTimeout(r.lockTimeout)
+
+		if r.backup {
+			fc = fc.WithBackup()
+		}
+
+		c, err := config.NewMemConfigurator(fc)
 		if err != nil {
 			return nil, err
 		}
@@ -101,3 +170,18 @@ func WithConfigFile(configFile string) Option {
 		r.configFile = filepath.Clean(configFile)
 	}
 }
+
+// WithConfigBackup keeps a backup of the previous configuration file, which is used when the configuration file is
+// corrupted.
+func WithConfigBackup() Option {
+	return func(r *FsRegistry) {
+		r.backup = true
+	}
+}
+
+// WithLockTimeout sets the duration to wait for the lock of the registry.
+func WithLockTimeout(d time.Duration) Option {
+	return func(r *FsRegistry) {
+		r.lockTimeout = d
+	}
+}
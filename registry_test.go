@@ -1,8 +1,10 @@
 package registry
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -11,6 +13,7 @@ import (
 
 	"github.com/nhatthm/plugin-registry/config"
 	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	"github.com/nhatthm/plugin-registry/plugin"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -88,3 +91,82 @@ func TestWithConfigFile(t *testing.T) {
 
 	assert.Equal(t, expected, r.config)
 }
+
+func TestWithLockTimeout(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()), WithLockTimeout(time.Second))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, r.lockTimeout)
+}
+
+func TestFsRegistry_Locked(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	err := afero.WriteFile(fs, "/tmp/.lock", nil, 0o644)
+	require.NoError(t, err)
+
+	r, err := NewRegistry("/tmp",
+		WithFs(fs),
+		WithConfigurator(configuratorMock.NoMock(t)),
+		WithLockTimeout(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	err = r.Enable("my-plugin")
+	require.ErrorIs(t, err, ErrRegistryLocked)
+
+	err = r.Disable("my-plugin")
+	require.ErrorIs(t, err, ErrRegistryLocked)
+
+	err = r.Uninstall("my-plugin")
+	require.ErrorIs(t, err, ErrRegistryLocked)
+
+	err = r.Install(context.Background(), "my-plugin")
+	require.ErrorIs(t, err, ErrRegistryLocked)
+
+	err = r.Upgrade(context.Background(), "my-plugin")
+	require.ErrorIs(t, err, ErrRegistryLocked)
+}
+
+func TestFsRegistry_ReloadAfterLock(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	r1, err := NewRegistry("/tmp", WithFs(fs))
+	require.NoError(t, err)
+
+	// Another process installs a disabled plugin after the registry is created.
+	r2, err := NewRegistry("/tmp", WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r2.config.SetPlugin(plugin.Plugin{Name: "my-plugin"}))
+
+	require.NoError(t, r1.Enable("my-plugin"))
+
+	p, err := r1.GetPlugin("my-plugin")
+	require.NoError(t, err)
+	require.NotNil(t, p)
+
+	assert.True(t, p.Enabled)
+}
+
+func TestWithConfigBackup(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	expected, err := config.NewMemConfigurator(
+		config.NewFileConfigurator("/tmp/config.yaml").WithFs(fs).WithBackup(),
+	)
+	require.NoError(t, err)
+
+	r, err := NewRegistry("/tmp", WithFs(fs), WithConfigBackup())
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, r.config)
+}
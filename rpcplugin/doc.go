@@ -0,0 +1,3 @@
+// Package rpcplugin provides a protocol for the installed plugins that run as long-running subprocesses and serve
+// net/rpc over a unix socket.
+package rpcplugin
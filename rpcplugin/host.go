@@ -0,0 +1,337 @@
+package rpcplugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/runner"
+)
+
+const (
+	defaultStartTimeout    = 10 * time.Second
+	defaultShutdownTimeout = 5 * time.Second
+)
+
+var (
+	// ErrPluginExited indicates that the plugin process exited.
+	ErrPluginExited = errors.New("plugin exited")
+	// ErrStartTimeout indicates that the plugin did not complete the handshake in time.
+	ErrStartTimeout = errors.New("plugin did not handshake in time")
+)
+
+// Option configures Host.
+type Option func(h *Host)
+
+// Host starts the installed plugins and connects to them.
+type Host struct {
+	runner *runner.Runner
+
+	capabilities    []string
+	startTimeout    time.Duration
+	shutdownTimeout time.Duration
+
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Start starts the plugin, reads its handshake line, connects to it and negotiates the capabilities. The context only
+// bounds the start, use Client.Shutdown() or Client.Kill() to stop the plugin.
+func (h *Host) Start(ctx context.Context, p plugin.Plugin) (*Client, error) {
+	procCtx, kill := context.WithCancel(context.Background())
+
+	cmd, err := h.runner.Command(procCtx, p)
+	if err != nil {
+		kill()
+
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+
+	cmd.Env = append(cmd.Env, ProtocolEnv+"="+strconv.Itoa(ProtocolVersion))
+	cmd.Stdout = pw
+	cmd.Stderr = h.stderr
+
+	if err := cmd.Start(); err != nil {
+		kill()
+
+		return nil, ctxd.WrapError(ctx, err, "could not start plugin", "name", p.Name)
+	}
+
+	c := &Client{
+		name:            p.Name,
+		kill:            kill,
+		shutdownTimeout: h.shutdownTimeout,
+		done:            make(chan struct{}),
+	}
+
+	go func() {
+		c.exitErr = cmd.Wait()
+
+		_ = pw.Close() //nolint: errcheck
+
+		close(c.done)
+	}()
+
+	handshake, err := h.readHandshake(ctx, c, pr)
+	if err != nil {
+		c.Kill()
+
+		return nil, ctxd.WrapError(ctx, err, "could not start plugin", "name", p.Name)
+	}
+
+	if err := c.connect(ctx, handshake, h.capabilities); err != nil {
+		c.Kill()
+
+		return nil, ctxd.WrapError(ctx, err, "could not start plugin", "name", p.Name)
+	}
+
+	return c, nil
+}
+
+func (h *Host) readHandshake(ctx context.Context, c *Client, r io.Reader) (Handshake, error) {
+	lines := make(chan string, 1)
+
+	go func() {
+		br := bufio.NewReader(r)
+
+		line, err := br.ReadString('\n')
+		if err == nil {
+			lines <- line
+		}
+
+		// Keep draining the output, the plugin is blocked otherwise.
+		_, _ = io.Copy(h.stdout, br) //nolint: errcheck
+	}()
+
+	timer := time.NewTimer(h.startTimeout)
+	defer timer.Stop()
+
+	select {
+	case line := <-lines:
+		return ParseHandshake(line)
+
+	case <-c.done:
+		return Handshake{}, ErrPluginExited
+
+	case <-timer.C:
+		return Handshake{}, ErrStartTimeout
+
+	case <-ctx.Done():
+		return Handshake{}, ctx.Err()
+	}
+}
+
+// New creates a new host for the plugins run by the runner.
+func New(r *runner.Runner, options ...Option) *Host {
+	h := &Host{
+		runner:          r,
+		startTimeout:    defaultStartTimeout,
+		shutdownTimeout: defaultShutdownTimeout,
+		stdout:          io.Discard,
+	}
+
+	for _, o := range options {
+		o(h)
+	}
+
+	return h
+}
+
+// WithCapabilities sets the capabilities that the host supports. The capabilities of the plugins that are not in the
+// list are ignored. If there is none, all the capabilities of the plugins are kept.
+func WithCapabilities(capabilities ...string) Option {
+	return func(h *Host) {
+		h.capabilities = append(h.capabilities, capabilities...)
+	}
+}
+
+// WithStartTimeout sets the duration to wait for the handshake of the plugins, the default is 10 seconds.
+func WithStartTimeout(d time.Duration) Option {
+	return func(h *Host) {
+		h.startTimeout = d
+	}
+}
+
+// WithShutdownTimeout sets the duration to wait for the plugins to exit on shutdown before killing them, the default
+// is 5 seconds.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(h *Host) {
+		h.shutdownTimeout = d
+	}
+}
+
+// WithStdout sets a writer that receives the standard output of the plugins after the handshake.
+func WithStdout(stdout io.Writer) Option {
+	return func(h *Host) {
+		h.stdout = stdout
+	}
+}
+
+// WithStderr sets a writer that receives the standard error of the plugins.
+func WithStderr(stderr io.Writer) Option {
+	return func(h *Host) {
+		h.stderr = stderr
+	}
+}
+
+// Client is a connection to a running plugin.
+type Client struct {
+	name            string
+	rpc             *rpc.Client
+	capabilities    []string
+	shutdownTimeout time.Duration
+
+	kill     context.CancelFunc
+	done     chan struct{}
+	exitErr  error
+	stopOnce sync.Once
+}
+
+func (c *Client) connect(ctx context.Context, h Handshake, capabilities []string) error {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, h.Network, h.Address)
+	if err != nil {
+		return err
+	}
+
+	c.rpc = rpc.NewClient(conn)
+
+	var reply HandshakeReply
+
+	if err := c.call(ctx, ControlService+".Handshake", HandshakeArgs{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    capabilities,
+	}, &reply); err != nil {
+		return err
+	}
+
+	c.capabilities = negotiate(capabilities, reply.Capabilities)
+
+	return nil
+}
+
+// Name returns the name of the plugin.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Capabilities returns the negotiated capabilities.
+func (c *Client) Capabilities() []string {
+	return append([]string{}, c.capabilities...)
+}
+
+// HasCapability checks whether the capability is negotiated or not.
+func (c *Client) HasCapability(capability string) bool {
+	for _, v := range c.capabilities {
+		if v == capability {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Call calls a method of a service of the plugin, such as "Greeter.Greet", and waits for the reply or the context.
+func (c *Client) Call(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	if err := c.call(ctx, serviceMethod, args, reply); err != nil {
+		return ctxd.WrapError(ctx, err, "could not call plugin", "name", c.name, "method", serviceMethod)
+	}
+
+	return nil
+}
+
+func (c *Client) call(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	select {
+	case <-c.done:
+		return ErrPluginExited
+
+	default:
+	}
+
+	call := c.rpc.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-call.Done:
+		return call.Error
+
+	case <-c.done:
+		return ErrPluginExited
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ping checks the health of the plugin.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.Call(ctx, ControlService+".Ping", Empty{}, &Empty{})
+}
+
+// Shutdown asks the plugin to stop and waits for it to exit. The plugin is killed if it does not exit before the
+// shutdown timeout or the context is done.
+func (c *Client) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.shutdownTimeout)
+	defer cancel()
+
+	err := c.call(ctx, ControlService+".Shutdown", Empty{}, &Empty{})
+
+	c.close()
+
+	if err == nil {
+		select {
+		case <-c.done:
+			return nil
+
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	c.Kill()
+
+	if errors.Is(err, ErrPluginExited) {
+		return nil
+	}
+
+	return ctxd.WrapError(ctx, err, "could not shut down plugin gracefully", "name", c.name)
+}
+
+// Kill kills the plugin and waits for it to exit.
+func (c *Client) Kill() {
+	c.close()
+	c.kill()
+
+	<-c.done
+}
+
+// Done returns a channel that is closed when the plugin exits.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait waits for the plugin to exit and returns its exit error.
+func (c *Client) Wait() error {
+	<-c.done
+
+	return c.exitErr
+}
+
+func (c *Client) close() {
+	c.stopOnce.Do(func() {
+		if c.rpc != nil {
+			_ = c.rpc.Close() //nolint: errcheck
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package rpcplugin_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/rpcplugin"
+	"github.com/nhatthm/plugin-registry/runner"
+)
+
+const (
+	fixturesDir    = "../resources/fixtures"
+	fixturePackage = "./testdata/my-rpc-plugin"
+)
+
+var registryDir string
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "rpcplugin-")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, "my-rpc-plugin", "my-rpc-plugin"), fixturePackage)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("could not build fixture plugin: %s", output))
+	}
+
+	registryDir = dir
+
+	return m.Run()
+}
+
+func fixturePlugin() plugin.Plugin {
+	return plugin.Plugin{
+		Name:    "my-rpc-plugin",
+		Version: "v1.0.0",
+		Enabled: true,
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}"},
+		},
+	}
+}
+
+func startPlugin(t *testing.T, options ...rpcplugin.Option) *rpcplugin.Client {
+	t.Helper()
+
+	c, err := rpcplugin.New(runner.New(registryDir), options...).Start(context.Background(), fixturePlugin())
+	require.NoError(t, err)
+
+	t.Cleanup(c.Kill)
+
+	return c
+}
+
+func TestHost_Start(t *testing.T) {
+	t.Parallel()
+
+	c := startPlugin(t)
+
+	var reply string
+
+	err := c.Call(context.Background(), "Greeter.Greet", "world", &reply)
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-rpc-plugin", c.Name())
+	assert.Equal(t, "hello world from my-rpc-plugin v1.0.0", reply)
+	assert.Equal(t, []string{"greeter", "streaming"}, c.Capabilities())
+}
+
+func TestHost_Start_Capabilities(t *testing.T) {
+	t.Parallel()
+
+	c := startPlugin(t, rpcplugin.WithCapabilities("greeter", "unknown"))
+
+	assert.Equal(t, []string{"greeter"}, c.Capabilities())
+	assert.True(t, c.HasCapability("greeter"))
+	assert.False(t, c.HasCapability("streaming"))
+}
+
+func TestHost_Start_Error(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario      string
+		path          string
+		name          string
+		file          string
+		env           string
+		expectedError error
+	}{
+		{
+			scenario:      "invalid handshake",
+			path:          fixturesDir,
+			name:          "my-plugin",
+			file:          "${name}",
+			expectedError: rpcplugin.ErrInvalidHandshake,
+		},
+		{
+			scenario:      "unsupported protocol version",
+			path:          registryDir,
+			name:          "my-rpc-plugin",
+			file:          "${name}",
+			env:           "MY_RPC_PLUGIN_HANDSHAKE=2|unix|/tmp/plugin.sock",
+			expectedError: rpcplugin.ErrProtocolVersion,
+		},
+		{
+			scenario:      "unsupported network",
+			path:          registryDir,
+			name:          "my-rpc-plugin",
+			file:          "${name}",
+			env:           "MY_RPC_PLUGIN_HANDSHAKE=1|tcp|127.0.0.1:8080",
+			expectedError: rpcplugin.ErrUnsupportedNetwork,
+		},
+		{
+			scenario:      "exited",
+			path:          registryDir,
+			name:          "my-rpc-plugin",
+			file:          "${name}",
+			env:           "MY_RPC_PLUGIN_EXIT=1",
+			expectedError: rpcplugin.ErrPluginExited,
+		},
+		{
+			scenario:      "not executable",
+			path:          "testdata",
+			name:          "my-rpc-plugin",
+			file:          "main.go",
+			expectedError: runner.ErrNotExecutable,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p := fixturePlugin()
+			p.Name = tc.name
+			p.Artifacts[plugin.RuntimeArtifactIdentifier()] = plugin.Artifact{File: tc.file}
+
+			c, err := rpcplugin.New(runner.New(tc.path, runner.WithEnv(tc.env))).Start(context.Background(), p)
+
+			assert.Nil(t, c)
+			require.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestHost_Start_Timeout(t *testing.T) {
+	t.Parallel()
+
+	r := runner.New(fixturesDir, runner.WithEnv("MY_PLUGIN_SLEEP=10"))
+	p := fixturePlugin()
+	p.Name = "my-plugin"
+
+	start := time.Now()
+
+	c, err := rpcplugin.New(r, rpcplugin.WithStartTimeout(50*time.Millisecond)).Start(context.Background(), p)
+
+	assert.Nil(t, c)
+	require.ErrorIs(t, err, rpcplugin.ErrStartTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestClient_Ping(t *testing.T) {
+	t.Parallel()
+
+	c := startPlugin(t)
+
+	require.NoError(t, c.Ping(context.Background()))
+}
+
+func TestClient_Ping_Unhealthy(t *testing.T) {
+	t.Parallel()
+
+	c, err := rpcplugin.New(runner.New(registryDir, runner.WithEnv("MY_RPC_PLUGIN_UNHEALTHY=1"))).
+		Start(context.Background(), fixturePlugin())
+	require.NoError(t, err)
+
+	t.Cleanup(c.Kill)
+
+	err = c.Ping(context.Background())
+
+	require.EqualError(t, err, "could not call plugin: unhealthy")
+}
+
+func TestClient_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	c := startPlugin(t)
+
+	require.NoError(t, c.Shutdown(context.Background()))
+
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("plugin is still running")
+	}
+
+	require.NoError(t, c.Wait())
+	require.ErrorIs(t, c.Ping(context.Background()), rpcplugin.ErrPluginExited)
+}
+
+func TestClient_Kill(t *testing.T) {
+	t.Parallel()
+
+	c := startPlugin(t)
+
+	c.Kill()
+
+	require.Error(t, c.Wait())
+}
+
+func TestServe_NotHosted(t *testing.T) {
+	t.Parallel()
+
+	err := rpcplugin.Serve(context.Background(), rpcplugin.ServeConfig{})
+
+	require.ErrorIs(t, err, rpcplugin.ErrNotHosted)
+}
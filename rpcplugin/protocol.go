@@ -0,0 +1,109 @@
+package rpcplugin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ProtocolVersion is the version of the protocol between the host and the plugins.
+	ProtocolVersion = 1
+
+	// ProtocolEnv is the environment variable that the host sets to the protocol version when it starts a plugin.
+	ProtocolEnv = "PLUGIN_RPC_PROTOCOL"
+
+	// ControlService is the name of the service that every plugin serves to handshake, check health and shut down.
+	ControlService = "Plugin"
+
+	handshakeNetwork   = "unix"
+	handshakeSeparator = "|"
+)
+
+var (
+	// ErrInvalidHandshake indicates that the handshake line of the plugin is malformed.
+	ErrInvalidHandshake = errors.New("invalid handshake")
+	// ErrProtocolVersion indicates that the plugin speaks another version of the protocol.
+	ErrProtocolVersion = errors.New("unsupported protocol version")
+	// ErrUnsupportedNetwork indicates that the plugin listens on another network than a unix socket.
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+)
+
+// Handshake is the first line that a plugin prints to its standard output, in the form
+// "<protocol version>|<network>|<address>", such as "1|unix|/tmp/plugin/plugin.sock".
+type Handshake struct {
+	ProtocolVersion int
+	Network         string
+	Address         string
+}
+
+// String satisfies fmt.Stringer.
+func (h Handshake) String() string {
+	return strings.Join([]string{strconv.Itoa(h.ProtocolVersion), h.Network, h.Address}, handshakeSeparator)
+}
+
+// ParseHandshake parses the handshake line of a plugin. The plugins must listen on a unix socket.
+func ParseHandshake(line string) (Handshake, error) {
+	parts := strings.Split(strings.TrimSpace(line), handshakeSeparator)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return Handshake{}, fmt.Errorf("%w: %q", ErrInvalidHandshake, line)
+	}
+
+	v, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Handshake{}, fmt.Errorf("%w: %q", ErrInvalidHandshake, line)
+	}
+
+	if v != ProtocolVersion {
+		return Handshake{}, fmt.Errorf("%w: %d", ErrProtocolVersion, v)
+	}
+
+	if parts[1] != handshakeNetwork {
+		return Handshake{}, fmt.Errorf("%w: %q", ErrUnsupportedNetwork, parts[1])
+	}
+
+	return Handshake{
+		ProtocolVersion: v,
+		Network:         parts[1],
+		Address:         parts[2],
+	}, nil
+}
+
+// HandshakeArgs is sent by the host to negotiate the capabilities.
+type HandshakeArgs struct {
+	ProtocolVersion int
+	Capabilities    []string
+}
+
+// HandshakeReply is sent by the plugin with the capabilities that it supports.
+type HandshakeReply struct {
+	Capabilities []string
+}
+
+// Empty is the arguments or the reply of the calls that have none.
+type Empty struct{}
+
+// negotiate returns the capabilities of the plugin that the host supports. If the host does not declare any, all the
+// capabilities of the plugin are kept.
+func negotiate(host, plugin []string) []string {
+	if len(host) == 0 {
+		return append([]string{}, plugin...)
+	}
+
+	supported := make(map[string]struct{}, len(host))
+
+	for _, c := range host {
+		supported[c] = struct{}{}
+	}
+
+	result := make([]string, 0, len(plugin))
+
+	for _, c := range plugin {
+		if _, ok := supported[c]; ok {
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
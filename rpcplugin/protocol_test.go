@@ -0,0 +1,86 @@
+package rpcplugin_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/rpcplugin"
+)
+
+func TestParseHandshake(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario       string
+		line           string
+		expectedResult rpcplugin.Handshake
+		expectedError  error
+	}{
+		{
+			scenario: "success",
+			line:     "1|unix|/tmp/plugin.sock\n",
+			expectedResult: rpcplugin.Handshake{
+				ProtocolVersion: 1,
+				Network:         "unix",
+				Address:         "/tmp/plugin.sock",
+			},
+		},
+		{
+			scenario:      "missing address",
+			line:          "1|unix",
+			expectedError: rpcplugin.ErrInvalidHandshake,
+		},
+		{
+			scenario:      "empty network",
+			line:          "1||/tmp/plugin.sock",
+			expectedError: rpcplugin.ErrInvalidHandshake,
+		},
+		{
+			scenario:      "invalid version",
+			line:          "v1|unix|/tmp/plugin.sock",
+			expectedError: rpcplugin.ErrInvalidHandshake,
+		},
+		{
+			scenario:      "unsupported version",
+			line:          "2|unix|/tmp/plugin.sock",
+			expectedError: rpcplugin.ErrProtocolVersion,
+		},
+		{
+			scenario:      "tcp network",
+			line:          "1|tcp|127.0.0.1:8080",
+			expectedError: rpcplugin.ErrUnsupportedNetwork,
+		},
+		{
+			scenario:      "unknown network",
+			line:          "1|udp|/tmp/plugin.sock",
+			expectedError: rpcplugin.ErrUnsupportedNetwork,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := rpcplugin.ParseHandshake(tc.line)
+
+			assert.Equal(t, tc.expectedResult, actual)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestHandshake_String(t *testing.T) {
+	t.Parallel()
+
+	h := rpcplugin.Handshake{ProtocolVersion: 1, Network: "unix", Address: "/tmp/plugin.sock"}
+
+	assert.Equal(t, "1|unix|/tmp/plugin.sock", h.String())
+}
@@ -0,0 +1,204 @@
+package rpcplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultServeShutdownTimeout = 5 * time.Second
+
+// ErrNotHosted indicates that the plugin is not started by a host that speaks the protocol.
+var ErrNotHosted = errors.New("plugin is not started by a host")
+
+// ServeConfig configures the plugin side of the protocol.
+type ServeConfig struct {
+	// Capabilities is the list of capabilities that the plugin supports.
+	Capabilities []string
+	// Services are registered to the rpc server by their names.
+	Services map[string]interface{}
+	// Health checks the health of the plugin. It is optional.
+	Health func() error
+	// Stdout receives the handshake line, the default is os.Stdout.
+	Stdout io.Writer
+	// ShutdownTimeout is the duration to wait for the host to close its connections on shutdown, the default is 5
+	// seconds.
+	ShutdownTimeout time.Duration
+}
+
+type control struct {
+	capabilities []string
+	health       func() error
+	shutdown     func()
+}
+
+// Handshake replies the capabilities of the plugin.
+func (c *control) Handshake(args HandshakeArgs, reply *HandshakeReply) error {
+	if args.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("%w: %d", ErrProtocolVersion, args.ProtocolVersion)
+	}
+
+	reply.Capabilities = append([]string{}, c.capabilities...)
+
+	return nil
+}
+
+// Ping checks the health of the plugin.
+func (c *control) Ping(_ Empty, _ *Empty) error {
+	if c.health == nil {
+		return nil
+	}
+
+	return c.health()
+}
+
+// Shutdown asks the plugin to stop serving.
+func (c *control) Shutdown(_ Empty, _ *Empty) error {
+	c.shutdown()
+
+	return nil
+}
+
+// Serve serves the plugin until the host asks it to shut down or the context is canceled. It listens on a unix socket
+// in a temporary directory and prints the handshake line, so it must be called by a plugin started by a host.
+//
+//	err := rpcplugin.Serve(ctx, rpcplugin.ServeConfig{
+//		Capabilities: []string{"greeter"},
+//		Services:     map[string]interface{}{"Greeter": &Greeter{}},
+//	})
+func Serve(ctx context.Context, cfg ServeConfig) error {
+	if os.Getenv(ProtocolEnv) != strconv.Itoa(ProtocolVersion) {
+		return ErrNotHosted
+	}
+
+	if cfg.Stdout == nil {
+		cfg.Stdout = os.Stdout
+	}
+
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = defaultServeShutdownTimeout
+	}
+
+	dir, err := os.MkdirTemp("", "plugin-registry-rpc-")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	l, err := net.Listen(handshakeNetwork, filepath.Join(dir, "plugin.sock"))
+	if err != nil {
+		return err
+	}
+
+	defer l.Close() //nolint: errcheck
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := rpc.NewServer()
+
+	if err := srv.RegisterName(ControlService, &control{
+		capabilities: cfg.Capabilities,
+		health:       cfg.Health,
+		shutdown:     cancel,
+	}); err != nil {
+		return err
+	}
+
+	for name, svc := range cfg.Services {
+		if err := srv.RegisterName(name, svc); err != nil {
+			return err
+		}
+	}
+
+	h := Handshake{ProtocolVersion: ProtocolVersion, Network: handshakeNetwork, Address: l.Addr().String()}
+
+	if _, err := fmt.Fprintln(cfg.Stdout, h.String()); err != nil {
+		return err
+	}
+
+	conns := newConnSet()
+
+	go func() {
+		<-ctx.Done()
+
+		_ = l.Close() //nolint: errcheck
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			break
+		}
+
+		conns.serve(srv, conn)
+	}
+
+	conns.close(cfg.ShutdownTimeout)
+
+	return nil
+}
+
+type connSet struct {
+	mu    sync.Mutex
+	wg    sync.WaitGroup
+	conns map[net.Conn]struct{}
+}
+
+func (s *connSet) serve(srv *rpc.Server, conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		srv.ServeConn(conn)
+
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+}
+
+// close waits for the host to close the connections, so the replies are delivered, then closes the remaining ones.
+func (s *connSet) close(timeout time.Duration) {
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+
+	case <-time.After(timeout):
+	}
+
+	s.mu.Lock()
+
+	for conn := range s.conns {
+		_ = conn.Close() //nolint: errcheck
+	}
+
+	s.mu.Unlock()
+
+	<-done
+}
+
+func newConnSet() *connSet {
+	return &connSet{conns: make(map[net.Conn]struct{})}
+}
@@ -0,0 +1,63 @@
+// Package main is a plugin that speaks the rpc protocol, it is built by the tests.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nhatthm/plugin-registry/rpcplugin"
+)
+
+// Greeter greets.
+type Greeter struct{}
+
+// Greet greets someone.
+func (Greeter) Greet(name string, reply *string) error {
+	*reply = fmt.Sprintf("hello %s from %s %s", name, os.Getenv("PLUGIN_NAME"), os.Getenv("PLUGIN_VERSION"))
+
+	return nil
+}
+
+func main() {
+	if os.Getenv("MY_RPC_PLUGIN_EXIT") != "" {
+		os.Exit(1)
+	}
+
+	if d, err := time.ParseDuration(os.Getenv("MY_RPC_PLUGIN_CRASH_AFTER")); err == nil {
+		go func() {
+			time.Sleep(d)
+			os.Exit(2)
+		}()
+	}
+
+	if line := os.Getenv("MY_RPC_PLUGIN_HANDSHAKE"); line != "" {
+		fmt.Println(line)
+		time.Sleep(time.Minute)
+
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rpcplugin.Serve(ctx, rpcplugin.ServeConfig{
+		Capabilities: []string{"greeter", "streaming"},
+		Services:     map[string]interface{}{"Greeter": Greeter{}},
+		Health: func() error {
+			if os.Getenv("MY_RPC_PLUGIN_UNHEALTHY") != "" {
+				return errors.New("unhealthy")
+			}
+
+			return nil
+		},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,2 @@
+// Package runner provides functionalities for running the installed plugins.
+package runner
@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+var (
+	// ErrPluginDisabled indicates that the plugin is disabled.
+	ErrPluginDisabled = errors.New("plugin is disabled")
+	// ErrNotExecutable indicates that the artifact of the plugin is not an executable file.
+	ErrNotExecutable = errors.New("plugin artifact is not executable")
+)
+
+// Option configures Runner.
+type Option func(r *Runner)
+
+// Result is the result of running a plugin.
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// Runner runs the installed plugins as subprocesses.
+type Runner struct {
+	path string
+	env  []string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Executable returns the absolute path of the runtime artifact of the plugin, it must be an executable file inside
+// the plugin directory.
+func (r *Runner) Executable(p plugin.Plugin) (string, error) {
+	pluginDir, err := filepath.Abs(filepath.Join(r.path, p.Name))
+	if err != nil {
+		return "", err
+	}
+
+	path, err := p.RuntimeArtifactPath(pluginDir)
+	if err != nil {
+		return "", ctxd.WrapError(context.Background(), err, "could not find plugin executable", "name", p.Name)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", ctxd.WrapError(context.Background(), err, "could not find plugin executable", "name", p.Name)
+	}
+
+	if fi.IsDir() || fi.Mode()&0o111 == 0 {
+		return "", ctxd.WrapError(context.Background(), ErrNotExecutable, "could not find plugin executable",
+			"name", p.Name,
+			"path", path,
+		)
+	}
+
+	return path, nil
+}
+
+// Command prepares the command to run the plugin. The environment of the current process is passed to the plugin,
+// along with PLUGIN_NAME, PLUGIN_VERSION, PLUGIN_DIR and the environment variables of the runner.
+func (r *Runner) Command(ctx context.Context, p plugin.Plugin, args ...string) (*exec.Cmd, error) {
+	if !p.Enabled {
+		return nil, ctxd.WrapError(ctx, ErrPluginDisabled, "could not run plugin", "name", p.Name)
+	}
+
+	path, err := r.Executable(p)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...) //nolint: gosec
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdin = r.stdin
+
+	cmd.Env = append(os.Environ(),
+		"PLUGIN_NAME="+p.Name,
+		"PLUGIN_VERSION="+p.Version,
+		"PLUGIN_DIR="+filepath.Dir(path),
+	)
+	cmd.Env = append(cmd.Env, r.env...)
+
+	return cmd, nil
+}
+
+// Run runs the plugin with the arguments and waits for it to exit. The output of the plugin is captured, and also
+// written to the stdout and stderr of the runner if any. A non-zero exit code is not an error, it is in the result.
+func (r *Runner) Run(ctx context.Context, p plugin.Plugin, args ...string) (Result, error) {
+	cmd, err := r.Command(ctx, p, args...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = teeWriter(&stdout, r.stdout)
+	cmd.Stderr = teeWriter(&stderr, r.stderr)
+
+	err = cmd.Run()
+
+	result := Result{
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}
+
+	if ctx.Err() != nil {
+		return result, ctxd.WrapError(ctx, ctx.Err(), "could not run plugin", "name", p.Name)
+	}
+
+	var exitErr *exec.ExitError
+
+	if err != nil && !errors.As(err, &exitErr) {
+		return result, ctxd.WrapError(ctx, err, "could not run plugin", "name", p.Name)
+	}
+
+	return result, nil
+}
+
+// New creates a new runner for the plugins installed in the registry directory.
+func New(path string, options ...Option) *Runner {
+	r := &Runner{
+		path: filepath.Clean(path),
+	}
+
+	for _, o := range options {
+		o(r)
+	}
+
+	return r
+}
+
+// WithEnv adds environment variables, in the form "key=value", to the plugins.
+func WithEnv(env ...string) Option {
+	return func(r *Runner) {
+		r.env = append(r.env, env...)
+	}
+}
+
+// WithStdin sets the standard input of the plugins.
+func WithStdin(stdin io.Reader) Option {
+	return func(r *Runner) {
+		r.stdin = stdin
+	}
+}
+
+// WithStdout sets a writer that receives the standard output of the plugins while it is captured.
+func WithStdout(stdout io.Writer) Option {
+	return func(r *Runner) {
+		r.stdout = stdout
+	}
+}
+
+// WithStderr sets a writer that receives the standard error of the plugins while it is captured.
+func WithStderr(stderr io.Writer) Option {
+	return func(r *Runner) {
+		r.stderr = stderr
+	}
+}
+
+func teeWriter(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(buf, w)
+}
@@ -0,0 +1,191 @@
+package runner_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/runner"
+)
+
+const fixturesDir = "../resources/fixtures"
+
+func fixturePlugin() plugin.Plugin {
+	return plugin.Plugin{
+		Name:    "my-plugin",
+		Version: "v1.0.0",
+		Enabled: true,
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "${name}"},
+		},
+	}
+}
+
+func TestRunner_Executable(t *testing.T) {
+	t.Parallel()
+
+	tempDir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "my-plugin"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "my-plugin", "my-plugin"), []byte("#!/bin/bash\n"), 0o644))
+
+	expected, err := filepath.Abs(filepath.Join(fixturesDir, "my-plugin", "my-plugin"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		scenario       string
+		path           string
+		file           string
+		expectedResult string
+		expectedError  error
+	}{
+		{
+			scenario:       "success",
+			path:           fixturesDir,
+			file:           "${name}",
+			expectedResult: expected,
+		},
+		{
+			scenario:      "not found",
+			path:          fixturesDir,
+			file:          "unknown",
+			expectedError: os.ErrNotExist,
+		},
+		{
+			scenario:      "plugin directory",
+			path:          filepath.Join(fixturesDir, ".."),
+			file:          ".",
+			expectedError: plugin.ErrInvalidArtifactPath,
+		},
+		{
+			scenario:      "outside of plugin directory",
+			path:          fixturesDir,
+			file:          "../.plugin.registry.yaml",
+			expectedError: plugin.ErrInvalidArtifactPath,
+		},
+		{
+			scenario:      "not executable",
+			path:          tempDir,
+			file:          "${name}",
+			expectedError: runner.ErrNotExecutable,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			p := fixturePlugin()
+			p.Artifacts[plugin.RuntimeArtifactIdentifier()] = plugin.Artifact{File: tc.file}
+
+			actual, err := runner.New(tc.path).Executable(p)
+
+			assert.Equal(t, tc.expectedResult, actual)
+
+			if tc.expectedError == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario       string
+		options        []runner.Option
+		args           []string
+		expectedResult runner.Result
+	}{
+		{
+			scenario: "success",
+			args:     []string{"world"},
+			expectedResult: runner.Result{
+				Stdout: []byte("hello world\n"),
+				Stderr: []byte("my-plugin v1.0.0\n"),
+			},
+		},
+		{
+			scenario: "with env",
+			options:  []runner.Option{runner.WithEnv("MY_PLUGIN_GREETING=hi")},
+			args:     []string{"there"},
+			expectedResult: runner.Result{
+				Stdout: []byte("hi there\n"),
+				Stderr: []byte("my-plugin v1.0.0\n"),
+			},
+		},
+		{
+			scenario: "exit code",
+			options:  []runner.Option{runner.WithEnv("MY_PLUGIN_EXIT_CODE=3")},
+			expectedResult: runner.Result{
+				ExitCode: 3,
+				Stdout:   []byte("hello\n"),
+				Stderr:   []byte("my-plugin v1.0.0\n"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := runner.New(fixturesDir, tc.options...).
+				Run(context.Background(), fixturePlugin(), tc.args...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
+
+func TestRunner_Run_Output(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+
+	r := runner.New(fixturesDir, runner.WithStdout(&stdout), runner.WithStderr(&stderr))
+
+	result, err := r.Run(context.Background(), fixturePlugin(), "world")
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello world\n", stdout.String())
+	assert.Equal(t, "my-plugin v1.0.0\n", stderr.String())
+	assert.Equal(t, stdout.Bytes(), result.Stdout)
+}
+
+func TestRunner_Run_Disabled(t *testing.T) {
+	t.Parallel()
+
+	p := fixturePlugin()
+	p.Enabled = false
+
+	_, err := runner.New(fixturesDir).Run(context.Background(), p)
+
+	require.EqualError(t, err, "could not run plugin: plugin is disabled")
+}
+
+func TestRunner_Run_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	_, err := runner.New(fixturesDir, runner.WithEnv("MY_PLUGIN_SLEEP=10")).Run(ctx, fixturePlugin())
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
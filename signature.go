@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// ErrArtifactNotSigned indicates that the signed metadata has no checksum of the runtime artifact, so the signature
+// does not cover it.
+var ErrArtifactNotSigned = errors.New("plugin artifact is not covered by the signature")
+
+// SignaturePolicy defines how the registry treats plugins that are not signed by a trusted key. It is only applied
+// when there are trusted keys, see WithTrustedKeys().
+type SignaturePolicy int
+
+const (
+	// SignatureRequired rejects unsigned and untrusted plugins.
+	SignatureRequired SignaturePolicy = iota
+	// SignatureAllowUnsigned allows plugins without signature, but rejects plugins signed by an untrusted key.
+	SignatureAllowUnsigned
+	// SignatureAllowUntrusted allows unsigned and untrusted plugins, only the trusted key is recorded.
+	SignatureAllowUntrusted
+)
+
+// verifySignature verifies the signature of the staged plugin and records the fingerprint of the key that signed it.
+// When the signature is required, the signed metadata must also have the checksum of the runtime artifact.
+func (r *FsRegistry) verifySignature(ctx context.Context, pluginDir string, p *plugin.Plugin) error {
+	// Only the registry decides who signed the plugin.
+	p.SignedBy = ""
+
+	if len(r.trustedKeys) == 0 {
+		return nil
+	}
+
+	key, err := plugin.VerifySignature(r.fs, pluginDir, r.trustedKeys)
+	if err == nil {
+		if r.signaturePolicy == SignatureRequired && p.RuntimeArtifact().Checksum == "" {
+			return ctxd.WrapError(ctx, ErrArtifactNotSigned, "could not verify plugin signature", "name", p.Name)
+		}
+
+		p.SignedBy = plugin.KeyFingerprint(key)
+
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, plugin.ErrUnsigned) && r.signaturePolicy != SignatureRequired,
+		errors.Is(err, plugin.ErrUntrusted) && r.signaturePolicy == SignatureAllowUntrusted:
+		return nil
+
+	case errors.Is(err, plugin.ErrUnsigned), errors.Is(err, plugin.ErrUntrusted):
+		return ctxd.WrapError(ctx, err, "could not verify plugin signature", "name", p.Name)
+	}
+
+	return err
+}
+
+// WithTrustedKeys sets the ed25519 public keys that are trusted to sign the plugins. Once set, the signature of the
+// plugins is verified on install and upgrade.
+func WithTrustedKeys(keys ...ed25519.PublicKey) Option {
+	return func(r *FsRegistry) {
+		r.trustedKeys = append(r.trustedKeys, keys...)
+	}
+}
+
+// WithSignaturePolicy sets the policy for the plugins that are not signed by a trusted key.
+func WithSignaturePolicy(policy SignaturePolicy) Option {
+	return func(r *FsRegistry) {
+		r.signaturePolicy = policy
+	}
+}
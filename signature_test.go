@@ -0,0 +1,176 @@
+package registry_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestRegistry_Install_Signature(t *testing.T) {
+	t.Parallel()
+
+	trustedKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	untrustedKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	trustedPublicKey := trustedKey.Public().(ed25519.PublicKey)
+
+	registerSigned := func(source string, p *plugin.Plugin, key ed25519.PrivateKey, files ...installerMock.File) {
+		if key != nil {
+			metadata, err := installerMock.Metadata(p)
+			require.NoError(t, err)
+
+			files = append(files, installerMock.File{
+				Path:    plugin.SignatureFile,
+				Content: base64.StdEncoding.EncodeToString(ed25519.Sign(key, metadata)),
+			})
+		}
+
+		installerMock.RegisterPlugin(source, p, files...)
+	}
+
+	sum := sha256.Sum256([]byte("hello"))
+	artifact := installerMock.File{Path: "my-plugin", Content: "hello", Mode: 0o755}
+
+	// The registry decides who signed the plugin, not the installer.
+	p := &plugin.Plugin{
+		Name:     "my-plugin",
+		SignedBy: "forged",
+		Artifacts: plugin.Artifacts{
+			plugin.RuntimeArtifactIdentifier(): {File: "my-plugin", Checksum: "sha256:" + hex.EncodeToString(sum[:])},
+		},
+	}
+
+	registerSigned("SIGNATURE_UNSIGNED", p, nil, artifact)
+	registerSigned("SIGNATURE_TRUSTED", p, trustedKey, artifact)
+	registerSigned("SIGNATURE_UNTRUSTED", p, untrustedKey, artifact)
+	registerSigned("SIGNATURE_NO_CHECKSUM", &plugin.Plugin{Name: "my-plugin"}, trustedKey)
+
+	testCases := []struct {
+		scenario         string
+		source           string
+		options          []registry.Option
+		expectedSignedBy string
+		expectedError    error
+	}{
+		{
+			scenario: "no trusted keys",
+			source:   "SIGNATURE_UNTRUSTED",
+		},
+		{
+			scenario:      "unsigned is rejected by default",
+			source:        "SIGNATURE_UNSIGNED",
+			options:       []registry.Option{registry.WithTrustedKeys(trustedPublicKey)},
+			expectedError: plugin.ErrUnsigned,
+		},
+		{
+			scenario:      "untrusted is rejected by default",
+			source:        "SIGNATURE_UNTRUSTED",
+			options:       []registry.Option{registry.WithTrustedKeys(trustedPublicKey)},
+			expectedError: plugin.ErrUntrusted,
+		},
+		{
+			scenario:         "trusted",
+			source:           "SIGNATURE_TRUSTED",
+			options:          []registry.Option{registry.WithTrustedKeys(trustedPublicKey)},
+			expectedSignedBy: plugin.KeyFingerprint(trustedPublicKey),
+		},
+		{
+			scenario:      "trusted without checksum is rejected by default",
+			source:        "SIGNATURE_NO_CHECKSUM",
+			options:       []registry.Option{registry.WithTrustedKeys(trustedPublicKey)},
+			expectedError: registry.ErrArtifactNotSigned,
+		},
+		{
+			scenario: "trusted without checksum is allowed with unsigned",
+			source:   "SIGNATURE_NO_CHECKSUM",
+			options: []registry.Option{
+				registry.WithTrustedKeys(trustedPublicKey),
+				registry.WithSignaturePolicy(registry.SignatureAllowUnsigned),
+			},
+			expectedSignedBy: plugin.KeyFingerprint(trustedPublicKey),
+		},
+		{
+			scenario: "unsigned is allowed",
+			source:   "SIGNATURE_UNSIGNED",
+			options: []registry.Option{
+				registry.WithTrustedKeys(trustedPublicKey),
+				registry.WithSignaturePolicy(registry.SignatureAllowUnsigned),
+			},
+		},
+		{
+			scenario: "untrusted is not allowed with unsigned",
+			source:   "SIGNATURE_UNTRUSTED",
+			options: []registry.Option{
+				registry.WithTrustedKeys(trustedPublicKey),
+				registry.WithSignaturePolicy(registry.SignatureAllowUnsigned),
+			},
+			expectedError: plugin.ErrUntrusted,
+		},
+		{
+			scenario: "untrusted is allowed",
+			source:   "SIGNATURE_UNTRUSTED",
+			options: []registry.Option{
+				registry.WithTrustedKeys(trustedPublicKey),
+				registry.WithSignaturePolicy(registry.SignatureAllowUntrusted),
+			},
+		},
+		{
+			scenario: "trusted with untrusted allowed",
+			source:   "SIGNATURE_TRUSTED",
+			options: []registry.Option{
+				registry.WithTrustedKeys(trustedPublicKey),
+				registry.WithSignaturePolicy(registry.SignatureAllowUntrusted),
+			},
+			expectedSignedBy: plugin.KeyFingerprint(trustedPublicKey),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			r, err := registry.NewRegistry("/tmp", append(tc.options, registry.WithFs(fs))...)
+			require.NoError(t, err)
+
+			err = r.Install(context.Background(), tc.source)
+
+			p, getErr := r.GetPlugin("my-plugin")
+			require.NoError(t, getErr)
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, p)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, p)
+			assert.Equal(t, tc.expectedSignedBy, p.SignedBy)
+
+			// The key is recorded in the configuration file.
+			cfg, err := afero.ReadFile(fs, "/tmp/config.yaml")
+			require.NoError(t, err)
+
+			if tc.expectedSignedBy == "" {
+				assert.NotContains(t, string(cfg), "signed_by")
+			} else {
+				assert.Contains(t, string(cfg), "signed_by: "+tc.expectedSignedBy)
+			}
+		})
+	}
+}
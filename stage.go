@@ -0,0 +1,210 @@
+package registry
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/bool64/ctxd"
+	"github.com/spf13/afero"
+
+	fsCtx "github.com/nhatthm/plugin-registry/context"
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+const stageDirPrefix = ".stage-"
+
+// stagePlugin installs a plugin into a temporary directory inside the registry and validates it against its metadata
+// and the request. The caller is responsible for removing the stage directory once the plugin is swapped into place.
+func (r *FsRegistry) stagePlugin(ctx context.Context, req installer.Request) (string, *plugin.Plugin, error) {
+	req, err := r.resolveIndex(ctx, req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The installer receives the source without the prefix of its name, if any.
+	i, src, err := installer.Resolve(fsCtx.WithFs(ctx, r.fs), req.Source)
+	if err != nil {
+		return "", nil, err
+	}
+
+	req.Source = src
+
+	stageDir, err := r.makeStageDir()
+	if err != nil {
+		return "", nil, err
+	}
+
+	p, err := i.Install(installer.WithRequest(ctx, req), stageDir, req.Source)
+	if err == nil {
+		p, err = r.loadStagedPlugin(ctx, stageDir, p)
+	}
+
+	if err == nil {
+		err = validateRequest(ctx, req, p)
+	}
+
+	if err == nil {
+		err = r.checkCompatibility(ctx, p, "could not install plugin")
+	}
+
+	if err == nil {
+		err = r.verifySignature(ctx, filepath.Join(stageDir, p.Name), p)
+	}
+
+	if err == nil {
+		err = verifyArtifact(r.fs, filepath.Join(stageDir, p.Name), p, req.Checksum)
+	}
+
+	if err != nil {
+		_ = r.fs.RemoveAll(stageDir) //nolint: errcheck
+
+		return "", nil, err
+	}
+
+	return stageDir, p, nil
+}
+
+// loadStagedPlugin loads the metadata of the staged plugin, so the registry checks and records what is in the plugin
+// directory, and signed, instead of what the installer returns. Only the revision is kept from the installer because it
+// is not part of the metadata.
+func (r *FsRegistry) loadStagedPlugin(ctx context.Context, stageDir string, installed *plugin.Plugin) (*plugin.Plugin, error) {
+	// The name is the directory of the plugin in the registry.
+	if !plugin.IsValidName(installed.Name) {
+		return nil, ctxd.WrapError(ctx, plugin.ErrInvalidName, "could not install plugin", "name", installed.Name)
+	}
+
+	p, err := plugin.Load(r.fs, filepath.Join(stageDir, installed.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Name != installed.Name {
+		return nil, ctxd.WrapError(ctx, ErrPluginMismatch, "could not install plugin",
+			"expected", installed.Name,
+			"actual", p.Name,
+		)
+	}
+
+	p.Revision = installed.Revision
+
+	return p, nil
+}
+
+// validateRequest checks whether the installed plugin is the requested one.
+func validateRequest(ctx context.Context, req installer.Request, p *plugin.Plugin) error {
+	if req.Name != "" && p.Name != req.Name {
+		return ctxd.WrapError(ctx, ErrPluginMismatch, "could not install plugin",
+			"expected", req.Name,
+			"actual", p.Name,
+		)
+	}
+
+	if req.Version.IsEmpty() {
+		return nil
+	}
+
+	ok, err := req.Version.CheckString(p.Version)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ctxd.WrapError(ctx, ErrVersionNotSatisfied, "could not install plugin",
+			"name", p.Name,
+			"version", p.Version,
+			"constraint", req.Version.String(),
+		)
+	}
+
+	return nil
+}
+
+// verifyArtifact verifies the runtime artifact of the plugin if the metadata has its checksum or its size. The
+// expected checksum, if any, is verified too.
+func verifyArtifact(fs afero.Fs, pluginDir string, p *plugin.Plugin, checksum string) error {
+	a := p.ResolveArtifact(p.RuntimeArtifact())
+
+	path, err := p.RuntimeArtifactPath(pluginDir)
+	if err != nil {
+		return err
+	}
+
+	if a.HasIntegrity() {
+		if err := a.Verify(fs, path); err != nil {
+			return err
+		}
+	}
+
+	if checksum == "" {
+		return nil
+	}
+
+	return plugin.Artifact{File: a.File, Checksum: checksum}.Verify(fs, path)
+}
+
+// makeStageDir creates a temporary directory inside the registry for staging a plugin.
+func (r *FsRegistry) makeStageDir() (string, error) {
+	if err := r.fs.MkdirAll(r.path, 0o755); err != nil {
+		return "", err
+	}
+
+	return afero.TempDir(r.fs, r.path, stageDirPrefix)
+}
+
+// removeStageDirs removes the stage directories that are left by the processes that crashed while installing a plugin.
+// The registry must be locked, and nothing staged yet, so that nobody else is staging a plugin. The directories are
+// removed on a best effort basis, they do not prevent installing the plugins.
+func (r *FsRegistry) removeStageDirs() {
+	entries, err := afero.ReadDir(r.fs, r.path)
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), stageDirPrefix) {
+			_ = r.fs.RemoveAll(filepath.Join(r.path, e.Name())) //nolint: errcheck
+		}
+	}
+}
+
+// swapPlugin replaces the installed plugin by the staged one and records it. The previous version is restored if the
+// configuration could not be updated.
+func (r *FsRegistry) swapPlugin(stageDir string, p plugin.Plugin) error {
+	pluginDir := filepath.Join(r.path, p.Name)
+	backupDir := filepath.Join(stageDir, ".backup")
+
+	hasBackup, err := afero.Exists(r.fs, pluginDir)
+	if err != nil {
+		return err
+	}
+
+	if hasBackup {
+		if err := r.fs.Rename(pluginDir, backupDir); err != nil {
+			return err
+		}
+	}
+
+	restore := func() {
+		_ = r.fs.RemoveAll(pluginDir) //nolint: errcheck
+
+		if hasBackup {
+			_ = r.fs.Rename(backupDir, pluginDir) //nolint: errcheck
+		}
+	}
+
+	if err := r.fs.Rename(filepath.Join(stageDir, p.Name), pluginDir); err != nil {
+		restore()
+
+		return err
+	}
+
+	if err := r.config.SetPlugin(p); err != nil {
+		restore()
+
+		return err
+	}
+
+	return nil
+}
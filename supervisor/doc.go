@@ -0,0 +1,2 @@
+// Package supervisor provides functionalities for keeping the enabled plugins running as long-running processes.
+package supervisor
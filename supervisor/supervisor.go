@@ -0,0 +1,386 @@
+package supervisor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/rpcplugin"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// State is the state of a supervised plugin.
+type State string
+
+const (
+	// StateStarting means that the plugin is being started.
+	StateStarting State = "starting"
+	// StateRunning means that the plugin is running.
+	StateRunning State = "running"
+	// StateBackoff means that the plugin crashed, or could not start, and waits to be restarted.
+	StateBackoff State = "backoff"
+	// StateFailed means that the plugin crashed too many times and is not restarted anymore.
+	StateFailed State = "failed"
+	// StateStopped means that the plugin is stopped.
+	StateStopped State = "stopped"
+)
+
+// Option configures Supervisor.
+type Option func(s *Supervisor)
+
+// Registry provides the installed plugins and their changes, it is satisfied by registry.FsRegistry.
+type Registry interface {
+	Config() (config.Configuration, error)
+	GetPlugin(name string) (*plugin.Plugin, error)
+	Subscribe(l registry.Listener) func()
+}
+
+// Status is the status of a supervised plugin.
+type Status struct {
+	Name  string
+	State State
+	// Restarts is the number of restarts since the plugin was last stable, that is running for longer than the maximum
+	// backoff.
+	Restarts  int
+	StartedAt time.Time
+	LastError error
+}
+
+// Supervisor starts the enabled plugins when they are installed or enabled, restarts them when they crash or are
+// upgraded, and stops them when they are disabled or uninstalled.
+type Supervisor struct {
+	registry Registry
+	host     *rpcplugin.Host
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+
+	mu          sync.Mutex
+	workers     map[string]*worker
+	statuses    map[string]Status
+	unsubscribe func()
+}
+
+// Start starts all the enabled plugins and follows the changes of the registry. The plugins that can not be started
+// are retried in the background, see Status().
+func (s *Supervisor) Start() error {
+	cfg, err := s.registry.Config()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.unsubscribe = s.registry.Subscribe(s.handle)
+	s.mu.Unlock()
+
+	for _, p := range cfg.Plugins {
+		if p.Enabled {
+			s.start(p)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops following the changes of the registry and shuts all the plugins down.
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+		s.unsubscribe = nil
+	}
+
+	names := make([]string, 0, len(s.workers))
+
+	for name := range s.workers {
+		names = append(names, name)
+	}
+
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for _, name := range names {
+		wg.Add(1)
+
+		go func(name string) {
+			defer wg.Done()
+
+			s.stop(name)
+		}(name)
+	}
+
+	wg.Wait()
+}
+
+// Status returns the status of the supervised plugins, sorted by name.
+func (s *Supervisor) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Status, 0, len(s.statuses))
+
+	for _, st := range s.statuses {
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Client returns the client of a running plugin.
+func (s *Supervisor) Client(name string) (*rpcplugin.Client, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.workers[name]
+	if !ok || w.client == nil {
+		return nil, false
+	}
+
+	return w.client, true
+}
+
+func (s *Supervisor) handle(e registry.Event) {
+	switch e.Type {
+	case registry.EventInstalled, registry.EventEnabled:
+		s.startEnabled(e.Name)
+
+	case registry.EventUpgraded:
+		// The new version is started if the plugin is still enabled.
+		s.stop(e.Name)
+		s.startEnabled(e.Name)
+
+	case registry.EventDisabled:
+		s.stop(e.Name)
+
+	case registry.EventUninstalled:
+		s.stop(e.Name)
+
+		s.mu.Lock()
+		delete(s.statuses, e.Name)
+		s.mu.Unlock()
+	}
+}
+
+// startEnabled starts the plugin if it is installed and enabled.
+func (s *Supervisor) startEnabled(name string) {
+	p, err := s.registry.GetPlugin(name)
+	if err != nil || p == nil || !p.Enabled {
+		return
+	}
+
+	s.start(*p)
+}
+
+func (s *Supervisor) start(p plugin.Plugin) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.workers[p.Name]; ok {
+		select {
+		case <-w.done:
+			// The plugin failed, start it again.
+
+		default:
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &worker{
+		plugin: p,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	s.workers[p.Name] = w
+	s.statuses[p.Name] = Status{Name: p.Name, State: StateStarting}
+
+	go s.run(ctx, w)
+}
+
+func (s *Supervisor) stop(name string) {
+	s.mu.Lock()
+	w, ok := s.workers[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	w.cancel()
+	<-w.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.workers[name] == w {
+		delete(s.workers, name)
+	}
+}
+
+// run keeps the plugin running until the context is canceled.
+func (s *Supervisor) run(ctx context.Context, w *worker) {
+	defer close(w.done)
+
+	backoff := s.initialBackoff
+	restarts := 0
+
+	for {
+		s.setStatus(w, func(st *Status) {
+			st.State = StateStarting
+			st.Restarts = restarts
+		})
+
+		startedAt := time.Now()
+
+		c, err := s.host.Start(ctx, w.plugin)
+		if err == nil {
+			s.setClient(w, c)
+			s.setStatus(w, func(st *Status) {
+				st.State = StateRunning
+				st.StartedAt = startedAt
+			})
+
+			select {
+			case <-ctx.Done():
+				err = c.Shutdown(context.Background())
+
+				s.setClient(w, nil)
+				s.setStatus(w, func(st *Status) {
+					st.State = StateStopped
+					st.LastError = err
+				})
+
+				return
+
+			case <-c.Done():
+				err = c.Wait()
+			}
+
+			s.setClient(w, nil)
+
+			// The plugin was stable for a while, it is a new crash.
+			if time.Since(startedAt) > s.maxBackoff {
+				backoff = s.initialBackoff
+				restarts = 0
+			}
+		}
+
+		if ctx.Err() != nil {
+			s.setStatus(w, func(st *Status) {
+				st.State = StateStopped
+			})
+
+			return
+		}
+
+		if s.maxRestarts > 0 && restarts >= s.maxRestarts {
+			s.setStatus(w, func(st *Status) {
+				st.State = StateFailed
+				st.LastError = err
+			})
+
+			return
+		}
+
+		s.setStatus(w, func(st *Status) {
+			st.State = StateBackoff
+			st.LastError = err
+		})
+
+		select {
+		case <-ctx.Done():
+			s.setStatus(w, func(st *Status) {
+				st.State = StateStopped
+			})
+
+			return
+
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+
+		restarts++
+	}
+}
+
+func (s *Supervisor) setStatus(w *worker, update func(st *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.statuses[w.plugin.Name]
+	st.Name = w.plugin.Name
+
+	update(&st)
+
+	s.statuses[w.plugin.Name] = st
+}
+
+func (s *Supervisor) setClient(w *worker, c *rpcplugin.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.client = c
+}
+
+type worker struct {
+	plugin plugin.Plugin
+	client *rpcplugin.Client
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a new supervisor for the enabled plugins of the registry, they are started by the host.
+func New(r Registry, h *rpcplugin.Host, options ...Option) *Supervisor {
+	s := &Supervisor{
+		registry:       r,
+		host:           h,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		workers:        make(map[string]*worker),
+		statuses:       make(map[string]Status),
+	}
+
+	for _, o := range options {
+		o(s)
+	}
+
+	return s
+}
+
+// WithBackoff sets the delays before restarting a crashed plugin, the delay starts with the initial one and doubles
+// after each crash up to the max one. The default is from 100 milliseconds to 30 seconds.
+func WithBackoff(initial, max time.Duration) Option {
+	return func(s *Supervisor) {
+		s.initialBackoff = initial
+		s.maxBackoff = max
+	}
+}
+
+// WithMaxRestarts sets the number of times in a row that a plugin is restarted before it is marked as failed. The
+// count is reset once the plugin runs for longer than the maximum backoff. The default is 0, the plugins are always
+// restarted.
+func WithMaxRestarts(n int) Option {
+	return func(s *Supervisor) {
+		s.maxRestarts = n
+	}
+}
@@ -0,0 +1,300 @@
+package supervisor_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	registry "github.com/nhatthm/plugin-registry"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+	"github.com/nhatthm/plugin-registry/rpcplugin"
+	"github.com/nhatthm/plugin-registry/runner"
+	"github.com/nhatthm/plugin-registry/supervisor"
+)
+
+const (
+	fixturePackage = "../rpcplugin/testdata/my-rpc-plugin"
+
+	waitFor = 5 * time.Second
+	tick    = 10 * time.Millisecond
+)
+
+var fixtureBinary string
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "supervisor-")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	fixtureBinary = filepath.Join(dir, "my-rpc-plugin")
+
+	cmd := exec.Command("go", "build", "-o", fixtureBinary, fixturePackage)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("could not build fixture plugin: %s", output))
+	}
+
+	return m.Run()
+}
+
+func newRegistry(t *testing.T) (*registry.FsRegistry, string) {
+	t.Helper()
+
+	registryDir := t.TempDir()
+
+	cfg := fmt.Sprintf(`plugins:
+    my-rpc-plugin:
+        name: my-rpc-plugin
+        version: v1.0.0
+        enabled: true
+        artifacts:
+            %[1]s/%[2]s:
+                file: my-rpc-plugin
+    other-plugin:
+        name: other-plugin
+        version: v1.0.0
+        enabled: false
+        artifacts:
+            %[1]s/%[2]s:
+                file: my-rpc-plugin
+`, runtime.GOOS, runtime.GOARCH)
+
+	require.NoError(t, os.WriteFile(filepath.Join(registryDir, "config.yaml"), []byte(cfg), 0o644))
+
+	for _, name := range []string{"my-rpc-plugin", "other-plugin"} {
+		data, err := os.ReadFile(filepath.Clean(fixtureBinary))
+		require.NoError(t, err)
+
+		require.NoError(t, os.MkdirAll(filepath.Join(registryDir, name), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(registryDir, name, "my-rpc-plugin"), data, 0o755)) //nolint: gosec
+	}
+
+	r, err := registry.NewRegistry(registryDir)
+	require.NoError(t, err)
+
+	return r, registryDir
+}
+
+func newSupervisor(t *testing.T, r *registry.FsRegistry, registryDir string, env []string, options ...supervisor.Option) *supervisor.Supervisor {
+	t.Helper()
+
+	h := rpcplugin.New(runner.New(registryDir, runner.WithEnv(env...)), rpcplugin.WithShutdownTimeout(time.Second))
+	s := supervisor.New(r, h, options...)
+
+	require.NoError(t, s.Start())
+
+	t.Cleanup(s.Stop)
+
+	return s
+}
+
+func stateOf(s *supervisor.Supervisor, name string) supervisor.State {
+	for _, st := range s.Status() {
+		if st.Name == name {
+			return st.State
+		}
+	}
+
+	return ""
+}
+
+func TestSupervisor_Start(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, nil)
+
+	assert.Eventually(t, func() bool {
+		return stateOf(s, "my-rpc-plugin") == supervisor.StateRunning
+	}, waitFor, tick)
+
+	c, ok := s.Client("my-rpc-plugin")
+	require.True(t, ok)
+
+	var reply string
+
+	require.NoError(t, c.Call(context.Background(), "Greeter.Greet", "world", &reply))
+	assert.Equal(t, "hello world from my-rpc-plugin v1.0.0", reply)
+
+	_, ok = s.Client("other-plugin")
+	assert.False(t, ok)
+	assert.Len(t, s.Status(), 1)
+
+	s.Stop()
+
+	assert.Equal(t, supervisor.StateStopped, stateOf(s, "my-rpc-plugin"))
+	assert.NoError(t, c.Wait())
+}
+
+func TestSupervisor_EnableDisable(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, nil)
+
+	require.NoError(t, r.Enable("other-plugin"))
+
+	assert.Eventually(t, func() bool {
+		return stateOf(s, "other-plugin") == supervisor.StateRunning
+	}, waitFor, tick)
+
+	require.NoError(t, r.Disable("other-plugin"))
+
+	assert.Equal(t, supervisor.StateStopped, stateOf(s, "other-plugin"))
+
+	_, ok := s.Client("other-plugin")
+	assert.False(t, ok)
+
+	require.NoError(t, r.Enable("other-plugin"))
+
+	assert.Eventually(t, func() bool {
+		return stateOf(s, "other-plugin") == supervisor.StateRunning
+	}, waitFor, tick)
+}
+
+func TestSupervisor_Uninstall(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, nil)
+
+	assert.Eventually(t, func() bool {
+		return stateOf(s, "my-rpc-plugin") == supervisor.StateRunning
+	}, waitFor, tick)
+
+	require.NoError(t, r.Uninstall("my-rpc-plugin"))
+
+	assert.Empty(t, s.Status())
+}
+
+func TestSupervisor_InstallUpgrade(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, nil)
+
+	binary, err := os.ReadFile(filepath.Clean(fixtureBinary))
+	require.NoError(t, err)
+
+	register := func(source, name, version string) {
+		installerMock.RegisterPlugin(source, &plugin.Plugin{
+			Name:    name,
+			Version: version,
+			Enabled: true,
+			Artifacts: plugin.Artifacts{
+				plugin.RuntimeArtifactIdentifier(): {File: "my-rpc-plugin"},
+			},
+		}, installerMock.File{Path: "my-rpc-plugin", Content: string(binary), Mode: 0o755})
+	}
+
+	greet := func(name string) string {
+		c, ok := s.Client(name)
+		if !ok {
+			return ""
+		}
+
+		var reply string
+
+		if err := c.Call(context.Background(), "Greeter.Greet", "world", &reply); err != nil {
+			return ""
+		}
+
+		return reply
+	}
+
+	register(t.Name()+"/new", "new-plugin", "v1.0.0")
+	register(t.Name()+"/upgrade", "my-rpc-plugin", "v2.0.0")
+
+	// The installed plugin is started.
+	require.NoError(t, r.Install(context.Background(), t.Name()+"/new"))
+
+	assert.Eventually(t, func() bool {
+		return greet("new-plugin") == "hello world from new-plugin v1.0.0"
+	}, waitFor, tick)
+
+	// The upgraded plugin is restarted with the new version.
+	assert.Eventually(t, func() bool {
+		return greet("my-rpc-plugin") == "hello world from my-rpc-plugin v1.0.0"
+	}, waitFor, tick)
+
+	require.NoError(t, r.Install(context.Background(), t.Name()+"/upgrade"))
+
+	assert.Eventually(t, func() bool {
+		return greet("my-rpc-plugin") == "hello world from my-rpc-plugin v2.0.0"
+	}, waitFor, tick)
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, []string{"MY_RPC_PLUGIN_CRASH_AFTER=50ms"},
+		supervisor.WithBackoff(10*time.Millisecond, time.Second),
+	)
+
+	assert.Eventually(t, func() bool {
+		st := s.Status()
+
+		return len(st) == 1 && st[0].Restarts >= 2
+	}, waitFor, tick)
+
+	assert.Error(t, s.Status()[0].LastError)
+}
+
+func TestSupervisor_MaxRestarts_Stable(t *testing.T) {
+	t.Parallel()
+
+	// The plugin runs for longer than the maximum backoff before crashing, every crash is a new one.
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, []string{"MY_RPC_PLUGIN_CRASH_AFTER=50ms"},
+		supervisor.WithBackoff(time.Millisecond, 20*time.Millisecond),
+		supervisor.WithMaxRestarts(1),
+	)
+
+	assert.Eventually(t, func() bool {
+		st := s.Status()
+
+		return len(st) == 1 && st[0].LastError != nil
+	}, waitFor, tick)
+
+	assert.Never(t, func() bool {
+		return stateOf(s, "my-rpc-plugin") == supervisor.StateFailed
+	}, 500*time.Millisecond, tick)
+
+	assert.LessOrEqual(t, s.Status()[0].Restarts, 1)
+}
+
+func TestSupervisor_MaxRestarts(t *testing.T) {
+	t.Parallel()
+
+	r, registryDir := newRegistry(t)
+	s := newSupervisor(t, r, registryDir, []string{"MY_RPC_PLUGIN_EXIT=1"},
+		supervisor.WithBackoff(time.Millisecond, time.Millisecond),
+		supervisor.WithMaxRestarts(2),
+	)
+
+	assert.Eventually(t, func() bool {
+		return stateOf(s, "my-rpc-plugin") == supervisor.StateFailed
+	}, waitFor, tick)
+
+	st := s.Status()[0]
+
+	assert.Equal(t, 2, st.Restarts)
+	require.ErrorIs(t, st.LastError, rpcplugin.ErrPluginExited)
+}
@@ -1,11 +1,44 @@
 package registry
 
 import (
+	"context"
 	"path/filepath"
 )
 
-// Uninstall uninstalls a plugin.
+// Uninstall uninstalls a plugin. It is refused if the plugin is required by other enabled plugins.
 func (r *FsRegistry) Uninstall(name string) error {
+	return r.uninstall(name, false)
+}
+
+// ForceUninstall uninstalls a plugin even if it is required by other enabled plugins.
+func (r *FsRegistry) ForceUninstall(name string) error {
+	return r.uninstall(name, true)
+}
+
+func (r *FsRegistry) uninstall(name string, force bool) error {
+	if err := r.removePlugin(name, force); err != nil {
+		return err
+	}
+
+	r.notify(Event{Type: EventUninstalled, Name: name})
+
+	return nil
+}
+
+func (r *FsRegistry) removePlugin(name string, force bool) error {
+	release, err := r.lock(context.Background())
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if !force {
+		if err := r.checkRequired(name, "could not remove plugin"); err != nil {
+			return err
+		}
+	}
+
 	if err := r.config.RemovePlugin(name); err != nil {
 		return err
 	}
@@ -2,14 +2,31 @@ package registry
 
 import (
 	"errors"
+	"os"
 	"testing"
 
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/mem"
 	"github.com/stretchr/testify/require"
 	"go.nhat.io/aferomock"
 
+	"github.com/nhatthm/plugin-registry/config"
 	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	"github.com/nhatthm/plugin-registry/plugin"
 )
 
+func expectRegistryLock(fs *aferomock.Fs) {
+	fs.On("MkdirAll", "/tmp", os.FileMode(0o755)).
+		Return(nil)
+
+	//nolint: nosnakecase
+	fs.On("OpenFile", "/tmp/.lock", os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o644)).
+		Return(mem.NewFileHandle(mem.CreateFile("/tmp/.lock")), nil)
+
+	fs.On("Remove", "/tmp/.lock").
+		Return(nil)
+}
+
 func TestRegistry_Uninstall(t *testing.T) {
 	t.Parallel()
 
@@ -19,9 +36,37 @@ func TestRegistry_Uninstall(t *testing.T) {
 		mockConfig    configuratorMock.Mocker
 		expectedError string
 	}{
+		{
+			scenario: "could not acquire lock",
+			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				fs.On("MkdirAll", "/tmp", os.FileMode(0o755)).
+					Return(errors.New("mkdir error"))
+			}),
+			mockConfig:    configuratorMock.NoMock,
+			expectedError: "mkdir error",
+		},
+		{
+			scenario: "required by enabled plugin",
+			mockFs:   aferomock.MockFs(expectRegistryLock),
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{Plugins: plugin.Plugins{
+						"other-plugin": {
+							Name:     "other-plugin",
+							Enabled:  true,
+							Requires: plugin.Requirements{{Name: "my-plugin"}},
+						},
+					}}, nil)
+			}),
+			expectedError: "could not remove plugin: plugin is required by other plugins",
+		},
 		{
 			scenario: "config error",
+			mockFs:   aferomock.MockFs(expectRegistryLock),
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("RemovePlugin", "my-plugin").
 					Return(errors.New("config error"))
 			}),
@@ -30,10 +75,15 @@ func TestRegistry_Uninstall(t *testing.T) {
 		{
 			scenario: "remove error",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectRegistryLock(fs)
+
 				fs.On("RemoveAll", "/tmp/my-plugin").
 					Return(errors.New("remove error"))
 			}),
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("RemovePlugin", "my-plugin").
 					Return(nil)
 			}),
@@ -42,10 +92,15 @@ func TestRegistry_Uninstall(t *testing.T) {
 		{
 			scenario: "success",
 			mockFs: aferomock.MockFs(func(fs *aferomock.Fs) {
+				expectRegistryLock(fs)
+
 				fs.On("RemoveAll", "/tmp/my-plugin").
 					Return(nil)
 			}),
 			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+
 				c.On("RemovePlugin", "my-plugin").
 					Return(nil)
 			}),
@@ -77,3 +132,49 @@ func TestRegistry_Uninstall(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry_ForceUninstall(t *testing.T) {
+	t.Parallel()
+
+	r, err := NewRegistry("/tmp",
+		WithFs(aferomock.MockFs(func(fs *aferomock.Fs) {
+			expectRegistryLock(fs)
+
+			fs.On("RemoveAll", "/tmp/my-plugin").
+				Return(nil)
+		})(t)),
+		WithConfigurator(configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+			c.On("RemovePlugin", "my-plugin").
+				Return(nil)
+		})(t)),
+	)
+	require.NoError(t, err)
+
+	err = r.ForceUninstall("my-plugin")
+
+	require.NoError(t, err)
+}
+
+func TestRegistry_Uninstall_VersionBeforeSemVer(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+
+	require.NoError(t, afero.WriteFile(fs, "/tmp/config.yaml", []byte(`plugins:
+    my-plugin:
+        name: my-plugin
+        url: ""
+        version: "1.0"
+        enabled: true
+`), 0o644))
+	require.NoError(t, fs.MkdirAll("/tmp/my-plugin", 0o755))
+
+	r, err := NewRegistry("/tmp", WithFs(fs))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Uninstall("my-plugin"))
+
+	p, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+	require.Nil(t, p)
+}
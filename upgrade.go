@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bool64/ctxd"
+
+	"github.com/nhatthm/plugin-registry/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+// ErrPluginNoSource indicates that the plugin does not have a source to upgrade from.
+var ErrPluginNoSource = errors.New("plugin has no source")
+
+// Upgrade upgrades a plugin by name if there is a newer version.
+func (r *FsRegistry) Upgrade(ctx context.Context, name string) error {
+	var events []Event
+
+	err := r.upgradePlugin(ctx, name, &events)
+
+	r.notifyAll(events)
+
+	return err
+}
+
+func (r *FsRegistry) upgradePlugin(ctx context.Context, name string, events *[]Event) error {
+	release, err := r.lock(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	r.removeStageDirs()
+
+	current, err := r.GetPlugin(name)
+	if err != nil {
+		return err
+	}
+
+	if current == nil {
+		return plugin.ErrPluginNotExist
+	}
+
+	if current.Source == "" {
+		return ErrPluginNoSource
+	}
+
+	stageDir, p, err := r.stagePlugin(ctx, installer.Request{Source: current.Source, Name: name})
+	if err != nil {
+		return err
+	}
+
+	defer r.fs.RemoveAll(stageDir) //nolint: errcheck
+
+	if !isUpgrade(current, p) {
+		return nil
+	}
+
+	if err := r.resolveDependencies(ctx, p, nil, events); err != nil {
+		return err
+	}
+
+	// Keep the state of the current plugin.
+	p.Enabled = current.Enabled
+
+	// Keep upgrading from the same source.
+	p.Source = current.Source
+
+	if err := r.swapPlugin(stageDir, *p); err != nil {
+		return err
+	}
+
+	*events = append(*events, Event{Type: EventUpgraded, Name: p.Name})
+
+	return nil
+}
+
+// UpgradeAll upgrades all the installed plugins that have a source. A failure does not stop the upgrade of the other
+// plugins, the first error is returned.
+func (r *FsRegistry) UpgradeAll(ctx context.Context) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+
+	for _, name := range sortedNames(cfg.Plugins) {
+		// The plugins installed before the source was recorded can not be upgraded.
+		if cfg.Plugins[name].Source == "" {
+			continue
+		}
+
+		if err := r.Upgrade(ctx, name); err != nil && firstErr == nil {
+			firstErr = ctxd.WrapError(ctx, err, "could not upgrade plugin", "name", name)
+		}
+	}
+
+	return firstErr
+}
+
+// isUpgrade checks whether the candidate plugin is an upgrade of the current one, either a newer version, or the same
+// version from another revision of the source.
+func isUpgrade(current, candidate *plugin.Plugin) bool {
+	if candidate.Revision != "" && candidate.Revision != current.Revision && candidate.Version == current.Version {
+		return true
+	}
+
+	return isNewerVersion(current.Version, candidate.Version)
+}
+
+// isNewerVersion checks whether the candidate version is newer than the current one. A candidate without a valid
+// version is never newer, and a plugin without a valid current version is always upgraded to a valid one.
+func isNewerVersion(current, candidate string) bool {
+	c, err := plugin.ParseVersion(candidate)
+	if err != nil {
+		return false
+	}
+
+	v, err := plugin.ParseVersion(current)
+	if err != nil {
+		return true
+	}
+
+	return c.GreaterThan(v)
+}
@@ -0,0 +1,112 @@
+package registry_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.nhat.io/aferocopy/v2"
+
+	registry "github.com/nhatthm/plugin-registry"
+	"github.com/nhatthm/plugin-registry/installer"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestIntegrationFsRegistry_Upgrade_KeepDisabledPlugin(t *testing.T) {
+	t.Parallel()
+
+	cfg := fmt.Sprintf(`plugins:
+    my-plugin:
+        name: my-plugin
+        url: https://example.org
+        version: v1.2.0
+        enabled: false
+        hidden: true
+        artifacts:
+            %s/%s:
+                file: my-plugin
+        tags:
+            - tag1
+        source: %s
+`, runtime.GOOS, runtime.GOARCH, t.Name())
+
+	registryDir := t.TempDir()
+	configFile := filepath.Join(registryDir, "config.yaml")
+	pluginDir := filepath.Join(registryDir, "my-plugin")
+
+	fs := afero.NewOsFs()
+	err := afero.WriteFile(fs, configFile, []byte(cfg), 0o755)
+	require.NoError(t, err)
+
+	err = fs.Mkdir(pluginDir, 0o755)
+	require.NoError(t, err)
+
+	// Register installer.
+	installer.Register(t.Name(), func(_ context.Context, source string) bool {
+		return source == t.Name()
+	}, func(afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(_ context.Context, dest, src string) (*plugin.Plugin, error) {
+			p := &plugin.Plugin{
+				Name:        "my-plugin",
+				URL:         "https://example.org",
+				Version:     "v1.10.0",
+				Description: "my plugin",
+				Enabled:     true,
+				Hidden:      true,
+				Artifacts: plugin.Artifacts{
+					plugin.RuntimeArtifactIdentifier(): {
+						File: "my-plugin",
+					},
+				},
+				Tags: plugin.Tags{"tag1"},
+			}
+
+			if err := aferocopy.Copy("resources/fixtures", filepath.Join(dest, "my-plugin")); err != nil {
+				return nil, err
+			}
+
+			return p, installerMock.InstallPlugin(afero.NewOsFs(), filepath.Join(dest, "my-plugin"), p)
+		})
+	})
+
+	// Upgrade plugin.
+	r, err := registry.NewRegistry(registryDir)
+	require.NoError(t, err)
+
+	err = r.UpgradeAll(context.Background())
+	require.NoError(t, err)
+
+	// Verify result.
+	exists, err := afero.Exists(fs, filepath.Join(pluginDir, plugin.MetadataFile))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	expected := fmt.Sprintf(`plugins:
+    my-plugin:
+        name: my-plugin
+        url: https://example.org
+        version: v1.10.0
+        description: my plugin
+        enabled: false
+        hidden: true
+        artifacts:
+            %s/%s:
+                file: my-plugin
+        tags:
+            - tag1
+        source: %s
+`, runtime.GOOS, runtime.GOARCH, t.Name())
+	actual, err := afero.ReadFile(fs, configFile)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(actual))
+
+	entries, err := afero.ReadDir(fs, registryDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,405 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nhatthm/plugin-registry/config"
+	"github.com/nhatthm/plugin-registry/installer"
+	configuratorMock "github.com/nhatthm/plugin-registry/mock/configurator"
+	installerMock "github.com/nhatthm/plugin-registry/mock/installer"
+	"github.com/nhatthm/plugin-registry/plugin"
+)
+
+func TestRegistry_Upgrade(t *testing.T) {
+	t.Parallel()
+
+	installer.Register("UPGRADE_FAIL", func(_ context.Context, src string) bool {
+		return src == "UPGRADE_FAIL"
+	}, func(afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+			return nil, errors.New("install error")
+		})
+	})
+
+	registerUpgrade := func(source string, p *plugin.Plugin) {
+		installerMock.RegisterPlugin(source, p, installerMock.File{Path: "version", Content: p.Version})
+	}
+
+	registerUpgrade("UPGRADE_MISMATCH", &plugin.Plugin{Name: "other-plugin", Version: "v2.0.0"})
+	registerUpgrade("UPGRADE_SAME", &plugin.Plugin{Name: "my-plugin", Version: "v1.0.0", Enabled: true})
+	registerUpgrade("UPGRADE_NEWER", &plugin.Plugin{Name: "my-plugin", Version: "v1.10.0", Enabled: true})
+	registerUpgrade("UPGRADE_SAME_REVISION", &plugin.Plugin{Name: "my-plugin", Version: "v1.0.0", Revision: "abc"})
+	registerUpgrade("UPGRADE_REVISION", &plugin.Plugin{Name: "my-plugin", Version: "v1.0.0", Revision: "def"})
+	registerUpgrade("UPGRADE_URL", &plugin.Plugin{Name: "my-plugin", URL: "https://github.com/acme/my-plugin", Version: "v1.10.0"})
+
+	configWith := func(source string) config.Configuration {
+		return config.Configuration{Plugins: plugin.Plugins{
+			"my-plugin": {Name: "my-plugin", URL: "https://example.org", Version: "v1.0.0", Source: source, Revision: "abc"},
+		}}
+	}
+
+	// The metadata is recorded as loaded, with the default artifact.
+	installed := func(source, version, revision string) plugin.Plugin {
+		return plugin.Plugin{
+			Name:    "my-plugin",
+			Version: version,
+			Artifacts: plugin.Artifacts{
+				plugin.RuntimeArtifactIdentifier(): {File: "${name}-${version}-${os}-${arch}.tar.gz"},
+			},
+			Tags:     plugin.Tags{},
+			Source:   source,
+			Revision: revision,
+		}
+	}
+
+	testCases := []struct {
+		scenario        string
+		mockConfig      configuratorMock.Mocker
+		expectedVersion string
+		expectedError   string
+	}{
+		{
+			scenario: "could not get config",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, errors.New("get config error"))
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "get config error",
+		},
+		{
+			scenario: "plugin does not exist",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(config.Configuration{}, nil)
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "plugin does not exist",
+		},
+		{
+			scenario: "plugin has no source",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith(""), nil)
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "plugin has no source",
+		},
+		{
+			scenario: "could not find installer",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UNKNOWN"), nil)
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "no supported installer",
+		},
+		{
+			scenario: "could not install",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_FAIL"), nil)
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "install error",
+		},
+		{
+			scenario: "plugin mismatch",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_MISMATCH"), nil)
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "could not install plugin: plugin mismatch",
+		},
+		{
+			scenario: "same version",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_SAME"), nil)
+			}),
+			expectedVersion: "v1.0.0",
+		},
+		{
+			scenario: "could not update configuration",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_NEWER"), nil)
+
+				c.On("SetPlugin", installed("UPGRADE_NEWER", "v1.10.0", "")).
+					Return(errors.New("config error"))
+			}),
+			expectedVersion: "v1.0.0",
+			expectedError:   "config error",
+		},
+		{
+			scenario: "newer version",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_NEWER"), nil)
+
+				c.On("SetPlugin", installed("UPGRADE_NEWER", "v1.10.0", "")).
+					Return(nil)
+			}),
+			expectedVersion: "v1.10.0",
+		},
+		{
+			scenario: "metadata has url",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_URL"), nil)
+
+				// The url of the metadata is recorded, the plugin is still upgraded from its source.
+				p := installed("UPGRADE_URL", "v1.10.0", "")
+				p.URL = "https://github.com/acme/my-plugin"
+
+				c.On("SetPlugin", p).
+					Return(nil)
+			}),
+			expectedVersion: "v1.10.0",
+		},
+		{
+			scenario: "same revision",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_SAME_REVISION"), nil)
+			}),
+			expectedVersion: "v1.0.0",
+		},
+		{
+			scenario: "new revision",
+			mockConfig: configuratorMock.Mock(func(c *configuratorMock.Configurator) {
+				c.On("Config").
+					Return(configWith("UPGRADE_REVISION"), nil)
+
+				c.On("SetPlugin", installed("UPGRADE_REVISION", "v1.0.0", "def")).
+					Return(nil)
+			}),
+			expectedVersion: "v1.0.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+
+			require.NoError(t, fs.MkdirAll("/tmp/my-plugin", 0o755))
+			require.NoError(t, afero.WriteFile(fs, "/tmp/my-plugin/version", []byte("v1.0.0"), 0o644))
+
+			r, err := NewRegistry("/tmp", WithFs(fs), WithConfigurator(tc.mockConfig(t)))
+			require.NoError(t, err)
+
+			err = r.Upgrade(context.Background(), "my-plugin")
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedError)
+			}
+
+			actual, err := afero.ReadFile(fs, "/tmp/my-plugin/version")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedVersion, string(actual))
+
+			// The stage directory must be cleaned up.
+			entries, err := afero.ReadDir(fs, "/tmp")
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestRegistry_Upgrade_MetadataURL(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+	p := func(version string) *plugin.Plugin {
+		return &plugin.Plugin{Name: "my-plugin", URL: "https://github.com/acme/my-plugin", Version: version}
+	}
+
+	installerMock.RegisterPlugin(source, p("v1.0.0"))
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source))
+
+	installerMock.RegisterPlugin(source, p("v1.1.0"))
+
+	require.NoError(t, r.Upgrade(context.Background(), "my-plugin"))
+
+	actual, err := r.GetPlugin("my-plugin")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.1.0", actual.Version)
+	assert.Equal(t, "https://github.com/acme/my-plugin", actual.URL)
+	assert.Equal(t, source, actual.Source)
+}
+
+func TestRegistry_UpgradeAll(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/a", &plugin.Plugin{Name: "plugin-a", Version: "v1.0.0", Enabled: true})
+	installerMock.RegisterPlugin(source+"/c", &plugin.Plugin{Name: "plugin-c", Version: "v1.0.0", Enabled: true})
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Install(context.Background(), source+"/a"))
+	require.NoError(t, r.Install(context.Background(), source+"/c"))
+
+	// A plugin installed before the source was recorded.
+	require.NoError(t, r.config.SetPlugin(plugin.Plugin{Name: "plugin-b", Version: "v1.0.0", Enabled: true}))
+
+	installerMock.RegisterPlugin(source+"/a", &plugin.Plugin{Name: "plugin-a", Version: "v2.0.0", Enabled: true})
+	installerMock.RegisterPlugin(source+"/c", &plugin.Plugin{Name: "plugin-c", Version: "v2.0.0", Enabled: true})
+
+	require.NoError(t, r.UpgradeAll(context.Background()))
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2.0.0", cfg.Plugins["plugin-a"].Version)
+	assert.Equal(t, "v1.0.0", cfg.Plugins["plugin-b"].Version)
+	assert.Equal(t, "v2.0.0", cfg.Plugins["plugin-c"].Version)
+}
+
+func TestRegistry_UpgradeAll_Error(t *testing.T) {
+	t.Parallel()
+
+	source := t.Name()
+
+	installerMock.RegisterPlugin(source+"/a", &plugin.Plugin{Name: "plugin-a", Version: "v1.0.0", Enabled: true})
+	installerMock.RegisterPlugin(source+"/b", &plugin.Plugin{Name: "plugin-b", Version: "v1.0.0", Enabled: true})
+	installerMock.RegisterPlugin(source+"/c", &plugin.Plugin{Name: "plugin-c", Version: "v1.0.0", Enabled: true})
+
+	r, err := NewRegistry("/tmp", WithFs(afero.NewMemMapFs()))
+	require.NoError(t, err)
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, r.Install(context.Background(), source+"/"+name))
+	}
+
+	// The source of plugin-a and plugin-b are broken.
+	installer.Register(source+"/a", func(_ context.Context, src string) bool {
+		return src == source+"/a"
+	}, func(afero.Fs) installer.Installer {
+		return installer.CallbackInstaller(func(context.Context, string, string) (*plugin.Plugin, error) {
+			return nil, errors.New("install error")
+		})
+	})
+
+	installerMock.RegisterPlugin(source+"/b", &plugin.Plugin{Name: "other-plugin", Version: "v2.0.0"})
+	installerMock.RegisterPlugin(source+"/c", &plugin.Plugin{Name: "plugin-c", Version: "v2.0.0", Enabled: true})
+
+	err = r.UpgradeAll(context.Background())
+	require.EqualError(t, err, "could not upgrade plugin: install error")
+
+	cfg, err := r.Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1.0.0", cfg.Plugins["plugin-a"].Version)
+	assert.Equal(t, "v1.0.0", cfg.Plugins["plugin-b"].Version)
+	assert.Equal(t, "v2.0.0", cfg.Plugins["plugin-c"].Version)
+}
+
+func TestIsUpgrade(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		scenario  string
+		current   plugin.Plugin
+		candidate plugin.Plugin
+		expected  bool
+	}{
+		{
+			scenario:  "newer version",
+			current:   plugin.Plugin{Version: "v1.0.0", Revision: "abc"},
+			candidate: plugin.Plugin{Version: "v1.1.0", Revision: "abc"},
+			expected:  true,
+		},
+		{
+			scenario:  "same version without revision",
+			current:   plugin.Plugin{Version: "v1.0.0"},
+			candidate: plugin.Plugin{Version: "v1.0.0"},
+		},
+		{
+			scenario:  "same version and revision",
+			current:   plugin.Plugin{Version: "v1.0.0", Revision: "abc"},
+			candidate: plugin.Plugin{Version: "v1.0.0", Revision: "abc"},
+		},
+		{
+			scenario:  "same version from another revision",
+			current:   plugin.Plugin{Version: "v1.0.0", Revision: "abc"},
+			candidate: plugin.Plugin{Version: "v1.0.0", Revision: "def"},
+			expected:  true,
+		},
+		{
+			scenario:  "no version from another revision",
+			current:   plugin.Plugin{Revision: "abc"},
+			candidate: plugin.Plugin{Revision: "def"},
+			expected:  true,
+		},
+		{
+			scenario:  "no version",
+			current:   plugin.Plugin{Version: "v1.0.0"},
+			candidate: plugin.Plugin{},
+		},
+		{
+			scenario:  "older version from another revision",
+			current:   plugin.Plugin{Version: "v1.1.0", Revision: "abc"},
+			candidate: plugin.Plugin{Version: "v1.0.0", Revision: "def"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.scenario, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, isUpgrade(&tc.current, &tc.candidate))
+		})
+	}
+}
+
+func TestIsNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		current   string
+		candidate string
+		expected  bool
+	}{
+		{current: "v1.0.0", candidate: "v1.0.0", expected: false},
+		{current: "v1.0.0", candidate: "v1.0.1", expected: true},
+		{current: "v1.9.0", candidate: "v1.10.0", expected: true},
+		{current: "v1.10.0", candidate: "v1.9.0", expected: false},
+		{current: "v1.0.0-rc.1", candidate: "v1.0.0", expected: true},
+		{current: "v1.0.0", candidate: "v1.0.0+build.1", expected: false},
+		{current: "", candidate: "v0.1.0", expected: true},
+		{current: "v1.0.0", candidate: "latest", expected: false},
+		{current: "v1.0.0", candidate: "", expected: false},
+		{current: "", candidate: "", expected: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.current+"_"+tc.candidate, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, isNewerVersion(tc.current, tc.candidate))
+		})
+	}
+}